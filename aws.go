@@ -12,19 +12,34 @@
 package aws
 
 import (
+	"errors"
 	"net/url"
 	"os"
+	"time"
 )
 
+// Returned when signing with credentials past their expiration.
+var ErrCredentialsExpired = errors.New("aws: credentials expired")
+
 type Credentials struct {
 	AccessKeyId     string
 	SecretAccessKey string
+	SessionToken    string    // temporary credentials only
+	Expiration      time.Time // zero if the credentials do not expire
+}
+
+// Expired returns true if the credentials have an expiration that has passed.
+func (creds *Credentials) Expired() bool {
+	return !creds.Expiration.IsZero() && !time.Now().Before(creds.Expiration)
 }
 
+// Getenv reads credentials from the environment. Missing variables are left
+// empty; use EnvProvider to get an error instead.
 func Getenv() *Credentials {
 	creds := &Credentials{
 		AccessKeyId:     os.Getenv("AWS_ACCESS_KEY_ID"),
 		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
 	}
 	return creds
 }
@@ -33,6 +48,7 @@ func Getenv() *Credentials {
 type Region struct {
 	Protocol string
 	Endpoint string
+	Name     string // region name used when signing (e.g. "us-east-1")
 }
 
 func (region *Region) Url(subdomain, path string, query url.Values) *url.URL {
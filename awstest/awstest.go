@@ -0,0 +1,357 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package awstest records the HTTP interactions of clients with AWS to cassette
+files and replays them, so that clients can be tested without network access
+or credentials.
+
+	func TestPutObject(t *testing.T) {
+		client := s3.NewClient(aws.DefaultProvider(), s3.USStandard)
+		awstest.Configure(t, client.Client, "testdata/putobject.json")
+		...
+	}
+
+Tests replay their cassettes unless $AWSTEST_RECORD is set, in which case
+requests are sent to AWS and the cassettes are rewritten when the tests end.
+
+	AWSTEST_RECORD=1 go test ./s3
+
+Authorization headers, security tokens and the keys of the client's
+credentials are scrubbed from recorded interactions. Requests are matched on
+their method, path, query and a hash of their body. The clock used to sign
+requests is frozen at the time the cassette was recorded, so the signatures
+of replayed requests are reproducible.
+*/
+package awstest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+// The environment variable which switches tests to recording.
+const RecordEnv = "AWSTEST_RECORD"
+
+// Replaces scrubbed values, as aws.RedactCredentials does.
+const Redacted = "REDACTED"
+
+// Recording returns true if $AWSTEST_RECORD is set to a true value.
+func Recording() bool {
+	record, _ := strconv.ParseBool(os.Getenv(RecordEnv))
+	return record
+}
+
+// Cassette is a recording of interactions with AWS.
+type Cassette struct {
+	Time         time.Time // the clock requests are signed with
+	Interactions []*Interaction
+}
+
+type Interaction struct {
+	Request  Request
+	Response Response
+}
+
+// Request is a recorded request. Its fields other than Header are matched
+// against replayed requests.
+type Request struct {
+	Method   string
+	Path     string
+	Query    string `json:",omitempty"` // without signature parameters
+	BodyHash string // hex encoded SHA-256
+	Header   http.Header
+}
+
+type Response struct {
+	StatusCode   int
+	Header       http.Header
+	Body         string
+	Base64       bool `json:",omitempty"` // Body is base64 encoded binary data
+	Uncompressed bool `json:",omitempty"` // see http.Response
+}
+
+// Request headers which are never recorded.
+var scrubbedHeaders = []string{"Authorization", "X-Amz-Security-Token"}
+
+// Query parameters of presigned requests which are not recorded or matched.
+var scrubbedParams = []string{"X-Amz-Credential", "X-Amz-Signature", "X-Amz-Security-Token"}
+
+// Recorder is an http.RoundTripper which records interactions to a cassette
+// or replays them from it. Each recorded interaction is replayed once, in
+// the order recorded. Safe for concurrent use.
+type Recorder struct {
+	// The cassette file.
+	Path string
+
+	// Sends requests while recording. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+
+	recording bool
+	mu        sync.Mutex
+	cassette  Cassette
+	replayed  []bool
+	secrets   []string
+}
+
+// NewRecorder returns a recorder for the cassette at path. Unless
+// recording, the cassette is read from path.
+func NewRecorder(path string, recording bool) (*Recorder, error) {
+	r := &Recorder{Path: path, recording: recording}
+	if recording {
+		r.cassette.Time = time.Now().UTC().Truncate(time.Second)
+		return r, nil
+	}
+	p, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("awstest: %v (set %s=1 to record it)", err, RecordEnv)
+	}
+	if err := json.Unmarshal(p, &r.cassette); err != nil {
+		return nil, fmt.Errorf("awstest: %s: %v", path, err)
+	}
+	r.replayed = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Recording returns true if r sends requests and records them.
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// Now returns the time the cassette was recorded. Use it as the clock of
+// request signers.
+func (r *Recorder) Now() time.Time {
+	return r.cassette.Time
+}
+
+// Scrub replaces secrets with Redacted wherever they occur in recorded
+// interactions.
+func (r *Recorder) Scrub(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+}
+
+func (r *Recorder) scrub(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.Replace(s, secret, Redacted, -1)
+	}
+	return s
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sum := sha256.Sum256(body)
+	recorded := Request{
+		Method:   req.Method,
+		Path:     req.URL.EscapedPath(),
+		Query:    matchQuery(req.URL),
+		BodyHash: hex.EncodeToString(sum[:]),
+	}
+	if r.recording {
+		return r.record(req, recorded, body)
+	}
+	return r.replay(req, recorded)
+}
+
+// The query of u without signature parameters, in a canonical order.
+func matchQuery(u *url.URL) string {
+	query := u.Query()
+	for _, param := range scrubbedParams {
+		query.Del(param)
+	}
+	return query.Encode()
+}
+
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] || !interaction.Request.matches(recorded) {
+			continue
+		}
+		r.replayed[i] = true
+		return interaction.Response.response(req)
+	}
+	return nil, fmt.Errorf("awstest: %s: no recorded response for %s %s", r.Path, req.Method, req.URL.RequestURI())
+}
+
+func (recorded Request) matches(req Request) bool {
+	return recorded.Method == req.Method &&
+		recorded.Path == req.Path &&
+		recorded.Query == req.Query &&
+		recorded.BodyHash == req.BodyHash
+}
+
+func (recorded Response) response(req *http.Request) (*http.Response, error) {
+	body := []byte(recorded.Body)
+	if recorded.Base64 {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(recorded.Body)
+		if err != nil {
+			return nil, fmt.Errorf("awstest: %v", err)
+		}
+	}
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorded.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Uncompressed:  recorded.Uncompressed,
+		Request:       req,
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	if req.Method == "HEAD" {
+		resp.ContentLength, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) record(req *http.Request, recorded Request, body []byte) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	req = req.Clone(req.Context())
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recorded.Query = r.scrub(recorded.Query)
+	recorded.Header = r.scrubHeader(req.Header)
+	for _, name := range scrubbedHeaders {
+		if recorded.Header.Get(name) != "" {
+			recorded.Header.Set(name, Redacted)
+		}
+	}
+	response := Response{
+		StatusCode:   resp.StatusCode,
+		Header:       r.scrubHeader(resp.Header),
+		Uncompressed: resp.Uncompressed,
+	}
+	if utf8.Valid(respBody) {
+		response.Body = r.scrub(string(aws.RedactCredentials(respBody)))
+	} else {
+		response.Body = base64.StdEncoding.EncodeToString(respBody)
+		response.Base64 = true
+	}
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{recorded, response})
+	return resp, nil
+}
+
+func (r *Recorder) scrubHeader(header http.Header) http.Header {
+	scrubbed := make(http.Header, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			scrubbed.Add(name, r.scrub(value))
+		}
+	}
+	return scrubbed
+}
+
+// Save writes the cassette to r.Path, creating its directory if needed.
+func (r *Recorder) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, err := json.MarshalIndent(&r.cassette, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(r.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.Path, append(p, '\n'), 0644)
+}
+
+// Configure makes client send its requests through a recorder for the
+// cassette at path, recording if Recording returns true. The client's
+// requests are signed at the time of the recording. When replaying, the
+// client's credentials are replaced by fake keys and retries are not
+// delayed. A recorded cassette is saved when the test ends.
+func Configure(t testing.TB, client *aws.Client, path string) *Recorder {
+	t.Helper()
+	r, err := NewRecorder(path, Recording())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.recording {
+		creds, err := client.Credentials.Retrieve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Scrub(creds.AccessKeyId, creds.SecretAccessKey, creds.SessionToken)
+		if client.HTTPClient != nil {
+			r.Transport = client.HTTPClient.Transport
+		}
+		t.Cleanup(func() {
+			if err := r.Save(); err != nil {
+				t.Error(err)
+			}
+		})
+	} else {
+		client.Credentials = &aws.Credentials{AccessKeyId: "AKIDEXAMPLE", SecretAccessKey: "SECRET"}
+		if client.Retryer != nil {
+			retryer := *client.Retryer
+			retryer.BaseDelay = time.Millisecond
+			retryer.MaxDelay = time.Millisecond
+			client.Retryer = &retryer
+		}
+	}
+	httpClient := new(http.Client)
+	if client.HTTPClient != nil {
+		*httpClient = *client.HTTPClient
+	}
+	httpClient.Transport = r
+	client.HTTPClient = httpClient
+	signer := *client.Signer
+	signer.Now = r.Now
+	client.Signer = &signer
+	return r
+}
@@ -0,0 +1,151 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package awstest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+// Runs a request through client, returning the response, its body and the
+// request's Authorization header.
+func testCall(client *aws.Client, method, path, body string) (*http.Response, string, string, error) {
+	var req *http.Request
+	op := &aws.Operation{
+		Name: "Test",
+		Build: func(op *aws.Operation) (err error) {
+			op.Request, err = http.NewRequest(method, client.Region.Url("", path, nil).String(), strings.NewReader(body))
+			req = op.Request
+			return err
+		},
+	}
+	err := client.Run(op)
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer op.Response.Body.Close()
+	p, err := ioutil.ReadAll(op.Response.Body)
+	return op.Response, string(p), req.Header.Get("Authorization"), err
+}
+
+func TestRecordReplay(t *testing.T) {
+	const sts = `<Credentials><AccessKeyId>ASIAROLE</AccessKeyId><SecretAccessKey>ROLESECRET</SecretAccessKey><SessionToken>ROLETOKEN</SessionToken></Credentials>`
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/creds":
+			w.Header().Set("Content-Type", "text/xml")
+			w.Write([]byte(sts))
+		case "/binary":
+			w.Write([]byte{0xff, 0xfe, 0})
+		case "/throttled":
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write(body)
+		}
+	}))
+	region := &aws.Region{Name: aws.USEast1, Protocol: "http", Endpoint: strings.TrimPrefix(server.URL, "http://")}
+	path := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+
+	t.Run("record", func(t *testing.T) {
+		t.Setenv(RecordEnv, "1")
+		creds := &aws.Credentials{AccessKeyId: "AKIDREAL", SecretAccessKey: "REALSECRET", SessionToken: "REALTOKEN"}
+		client := aws.NewClient("test", creds, region)
+		Configure(t, client, path)
+		for _, call := range [][3]string{{"GET", "/creds", ""}, {"GET", "/binary", ""}, {"PUT", "/throttled", "hello"}} {
+			if _, _, _, err := testCall(client, call[0], call[1], call[2]); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	server.Close()
+
+	p, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"AKIDREAL", "REALSECRET", "REALTOKEN", "ASIAROLE", "ROLESECRET", "ROLETOKEN", "Signature="} {
+		if bytes.Contains(p, []byte(secret)) {
+			t.Errorf("cassette contains %q:\n%s", secret, p)
+		}
+	}
+
+	t.Setenv(RecordEnv, "")
+	client := aws.NewClient("test", aws.DefaultProvider(), region)
+	r := Configure(t, client, path)
+	resp, body, auth, err := testCall(client, "GET", "/creds", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<Credentials><AccessKeyId>REDACTED</AccessKeyId><SecretAccessKey>REDACTED</SecretAccessKey><SessionToken>REDACTED</SessionToken></Credentials>`
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "text/xml" || body != want {
+		t.Errorf("response %d %q %q", resp.StatusCode, resp.Header, body)
+	}
+	if !strings.Contains(auth, r.Now().Format("20060102")) {
+		t.Errorf("authorization not signed at %v: %q", r.Now(), auth)
+	}
+	_, body, _, err = testCall(client, "PUT", "/throttled", "hello")
+	if err != nil || body != "hello" {
+		t.Errorf("replaying a retried request: %q %v", body, err)
+	}
+	_, body, _, err = testCall(client, "GET", "/binary", "")
+	if err != nil || body != "\xff\xfe\x00" {
+		t.Errorf("replaying a binary response: %q %v", body, err)
+	}
+	_, _, _, err = testCall(client, "GET", "/creds", "")
+	if err == nil || !strings.Contains(err.Error(), "no recorded response for GET /creds") {
+		t.Errorf("replaying an interaction twice: %v", err)
+	}
+	_, _, _, err = testCall(client, "PUT", "/throttled", "goodbye")
+	if err == nil {
+		t.Errorf("replayed a request with a different body")
+	}
+
+	// A second replay signs requests identically.
+	client2 := aws.NewClient("test", nil, region)
+	Configure(t, client2, path)
+	_, _, auth2, err := testCall(client2, "GET", "/creds", "")
+	if err != nil || auth2 != auth {
+		t.Errorf("authorization %q != %q (%v)", auth2, auth, err)
+	}
+}
+
+func TestRecorderBinary(t *testing.T) {
+	recorded := Response{StatusCode: 200, Body: "//4A", Base64: true}
+	req, _ := http.NewRequest("GET", "http://example.com/binary", nil)
+	resp, err := recorded.response(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !bytes.Equal(body, []byte{0xff, 0xfe, 0}) || resp.ContentLength != 3 {
+		t.Errorf("body %q (%d)", body, resp.ContentLength)
+	}
+}
+
+func TestMatchQuery(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://example.com/?b=2&a=1&X-Amz-Signature=abc&X-Amz-Credential=AKID%2F", nil)
+	if q := matchQuery(req.URL); q != "a=1&b=2" {
+		t.Errorf("query %q", q)
+	}
+}
+
+func TestMissingCassette(t *testing.T) {
+	_, err := NewRecorder(filepath.Join(t.TempDir(), "missing.json"), false)
+	if err == nil || !strings.Contains(err.Error(), RecordEnv) {
+		t.Errorf("error %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// Operation is the state of one API call as it passes through the phases of
+// a Client.
+type Operation struct {
+	Context context.Context
+	Service string // the client's signing name (e.g. "s3")
+	Name    string // the API call (e.g. "GetObject")
+
+	// Attempt counts the times the request has been built, starting at one.
+	Attempt int
+
+	// Unsigned operations skip the sign phase's default handler.
+	Unsigned bool
+
+	// Supplied by the service: Build sets Request and Unmarshal decodes
+	// Response into Output. Unmarshal may be nil.
+	Build     Handler
+	Unmarshal Handler
+
+	Request  *http.Request
+	Response *http.Response
+	Output   interface{}
+
+	// Describe the call in trace spans (e.g. "aws.s3.bucket"). May be set
+	// by Build.
+	Attributes map[string]interface{}
+
+	// Set by the sign phase's default handler.
+	CanonicalRequest string
+	StringToSign     string
+}
+
+// OperationName returns the name of req's type, which by convention is the
+// name of the API call it makes.
+func OperationName(req interface{}) string {
+	t := reflect.TypeOf(req)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
+
+// A Handler performs a phase of an operation.
+type Handler func(op *Operation) error
+
+// Middleware wraps the handler of a phase. It may act before or after
+// calling next, or return without calling it.
+type Middleware func(next Handler) Handler
+
+type Phase int
+
+const (
+	BuildPhase     Phase = iota // sets op.Request
+	SignPhase                   // signs op.Request
+	SendPhase                   // sets op.Response, failing on error responses
+	UnmarshalPhase              // decodes op.Response into op.Output
+	numPhases
+)
+
+var phaseNames = [numPhases]string{"build", "sign", "send", "unmarshal"}
+
+func (phase Phase) String() string {
+	if phase < 0 || phase >= numPhases {
+		return "unknown"
+	}
+	return phaseNames[phase]
+}
+
+// The build, sign and send phases run once per attempt; the unmarshal phase
+// runs once after the final attempt succeeds.
+type Handlers struct {
+	phases [numPhases][]namedMiddleware
+}
+
+type namedMiddleware struct {
+	name string
+	m    Middleware
+}
+
+// Add appends m to the phase's middleware, replacing any with the same
+// name. The first middleware added is the outermost.
+func (h *Handlers) Add(phase Phase, name string, m Middleware) {
+	for i, nm := range h.phases[phase] {
+		if nm.name == name {
+			h.phases[phase][i].m = m
+			return
+		}
+	}
+	h.phases[phase] = append(h.phases[phase], namedMiddleware{name, m})
+}
+
+// Remove deletes the named middleware from the phase.
+func (h *Handlers) Remove(phase Phase, name string) {
+	list := h.phases[phase]
+	for i, nm := range list {
+		if nm.name == name {
+			h.phases[phase] = append(list[:i:i], list[i+1:]...)
+			return
+		}
+	}
+}
+
+// Copy returns handlers which can be modified independently of h.
+func (h *Handlers) Copy() Handlers {
+	var c Handlers
+	for i := range h.phases {
+		c.phases[i] = append([]namedMiddleware(nil), h.phases[i]...)
+	}
+	return c
+}
+
+func (h *Handlers) wrap(phase Phase, handler Handler) Handler {
+	list := h.phases[phase]
+	for i := len(list) - 1; i >= 0; i-- {
+		handler = list[i].m(handler)
+	}
+	return handler
+}
+
+// Client is the core of the service clients. Service packages embed a
+// *Client and run each API call as an Operation. The exported fields should
+// not be changed while operations are running.
+type Client struct {
+	Credentials CredentialsProvider
+	Region      *Region
+	Retryer     *Retryer // nil to disable retries
+
+	// Sends requests. See NewHTTPClient for timeouts, proxies and TLS.
+	HTTPClient *http.Client
+
+	// Identifies the application in the User-Agent header, after the
+	// library's UserAgent (e.g. "myapp/1.2").
+	UserAgent string
+
+	// Receives a measurement of every request sent. May be nil.
+	Metrics Metrics
+
+	// Traces each call and attempt. May be nil.
+	Tracer Tracer
+
+	// Template for signing requests; its Region is taken from the client's.
+	// Its clock (time.Now if nil) is corrected by ClockOffset.
+	Signer *Signer
+
+	// Returns an error for an unsuccessful response, closing its body.
+	// Returns nil for successful responses.
+	ParseError func(resp *http.Response) error
+
+	Handlers Handlers
+
+	clockOffset atomic.Int64 // see ClockOffset
+}
+
+// NewClient returns a client for the service signed as signingName.
+func NewClient(signingName string, creds CredentialsProvider, region *Region) *Client {
+	return &Client{
+		Credentials: creds,
+		Region:      region,
+		Retryer:     NewRetryer(),
+		HTTPClient:  NewHTTPClient(nil),
+		Signer:      &Signer{Service: signingName},
+	}
+}
+
+// Run executes op, retrying the build, sign and send phases according to
+// client.Retryer. A request rejected because the local clock is skewed is
+// sent once more, without counting as a retry, signed with the corrected
+// clock. op.Response is set even when an error is returned.
+func (client *Client) Run(op *Operation) (err error) {
+	if op.Context == nil {
+		op.Context = context.Background()
+	}
+	if op.Service == "" {
+		op.Service = client.Signer.Service
+	}
+	ctx, span := client.startSpan(op.Context, op.Service+"."+op.Name)
+	op.Context = ctx
+	span.SetAttribute(AttrService, op.Service)
+	span.SetAttribute(AttrOperation, op.Name)
+	span.SetAttribute(AttrRegion, client.Region.Name)
+	defer func() { endSpan(span, op, err) }()
+
+	build := client.Handlers.wrap(BuildPhase, func(op *Operation) error {
+		if op.Build == nil {
+			return errors.New("aws: operation has no build handler")
+		}
+		return op.Build(op)
+	})
+	sign := client.Handlers.wrap(SignPhase, client.sign)
+	send := client.Handlers.wrap(SendPhase, client.send)
+	attempt := func() (*http.Response, error) {
+		op.Attempt++
+		op.Request = nil
+		op.Response = nil
+		var attemptSpan Span
+		op.Context, attemptSpan = client.startSpan(ctx, "attempt")
+		defer func() { op.Context = ctx }()
+		err := build(op)
+		if err == nil {
+			op.Request.Header.Set("User-Agent", client.userAgent())
+			injectTrace(op.Context, op.Request)
+			err = sign(op)
+		}
+		if err == nil {
+			err = send(op)
+		}
+		endSpan(attemptSpan, op, err)
+		return op.Response, err
+	}
+	skewed := false
+	resp, err := client.Retryer.Do(ctx, func() (*http.Response, error) {
+		resp, err := attempt()
+		if err != nil && !skewed && client.correctSkew(resp, err) {
+			skewed = true
+			resp.Body.Close()
+			resp, err = attempt()
+		}
+		return resp, err
+	})
+	op.Response = resp
+	if err != nil {
+		return err
+	}
+	unmarshal := client.Handlers.wrap(UnmarshalPhase, func(op *Operation) error {
+		if op.Unmarshal == nil {
+			return nil
+		}
+		return op.Unmarshal(op)
+	})
+	return unmarshal(op)
+}
+
+// Sign signs req with the client's credentials.
+func (client *Client) Sign(req *http.Request) error {
+	_, _, err := client.signRequest(req)
+	return err
+}
+
+func (client *Client) signRequest(req *http.Request) (creq, tosign string, err error) {
+	if client.Credentials == nil {
+		return "", "", errors.New("aws: no credentials")
+	}
+	creds, err := client.Credentials.Retrieve()
+	if err != nil {
+		return "", "", err
+	}
+	return client.NewSigner().sign(creds, req)
+}
+
+// NewSigner returns a copy of client.Signer using the client's region and
+// clock.
+func (client *Client) NewSigner() *Signer {
+	signer := *client.Signer
+	signer.Region = client.Region.Name
+	signer.Now = client.Now
+	return &signer
+}
+
+func (client *Client) sign(op *Operation) (err error) {
+	op.CanonicalRequest, op.StringToSign = "", ""
+	if op.Unsigned {
+		return nil
+	}
+	op.CanonicalRequest, op.StringToSign, err = client.signRequest(op.Request)
+	return err
+}
+
+func (client *Client) send(op *Operation) error {
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	var m *AttemptMetrics
+	if client.Metrics != nil {
+		m = newAttemptMetrics(op)
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(op.Request.WithContext(op.Context))
+	if err != nil {
+		if m != nil {
+			m.Latency = time.Since(start)
+			m.setResult(nil, err)
+			client.Metrics.ObserveAttempt(m)
+		}
+		return err
+	}
+	op.Response = resp
+	var body *meteredBody
+	if m != nil {
+		m.Latency = time.Since(start)
+		body = &meteredBody{ReadCloser: resp.Body, m: m}
+		resp.Body = body
+	}
+	if client.ParseError != nil {
+		err = client.ParseError(resp)
+	}
+	if m != nil {
+		m.setResult(resp, err)
+		body.reportTo(client.Metrics.ObserveAttempt)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package aws
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testServiceClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	region := &Region{Name: USEast1, Protocol: "http", Endpoint: strings.TrimPrefix(server.URL, "http://")}
+	client := NewClient("test", &Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}, region)
+	client.Retryer.BaseDelay = time.Millisecond
+	return client
+}
+
+func testOperation(client *Client) *Operation {
+	return &Operation{
+		Name: "Test",
+		Build: func(op *Operation) (err error) {
+			op.Request, err = http.NewRequest("GET", client.Region.Url("", "/", nil).String(), nil)
+			return err
+		},
+		Unmarshal: func(op *Operation) error {
+			defer op.Response.Body.Close()
+			p, err := ioutil.ReadAll(op.Response.Body)
+			op.Output = string(p)
+			return err
+		},
+	}
+}
+
+func TestClientHandlers(t *testing.T) {
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Custom") != "yes" {
+			t.Errorf("missing custom header")
+		}
+		if !strings.Contains(r.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-custom,") {
+			t.Errorf("custom header not signed: %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte("ok"))
+	})
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(op *Operation) error {
+				calls = append(calls, name)
+				return next(op)
+			}
+		}
+	}
+	client.Handlers.Add(BuildPhase, "trace", trace("build"))
+	client.Handlers.Add(BuildPhase, "custom", func(next Handler) Handler {
+		return func(op *Operation) error {
+			err := next(op)
+			if err == nil {
+				op.Request.Header.Set("X-Custom", "yes")
+			}
+			return err
+		}
+	})
+	client.Handlers.Add(SignPhase, "trace", trace("sign"))
+	client.Handlers.Add(SendPhase, "trace", trace("send"))
+	client.Handlers.Add(UnmarshalPhase, "trace", trace("unmarshal"))
+	client.Handlers.Add(UnmarshalPhase, "removed", trace("removed"))
+	client.Handlers.Remove(UnmarshalPhase, "removed")
+
+	op := testOperation(client)
+	if err := client.Run(op); err != nil {
+		t.Fatal(err)
+	}
+	if op.Output != "ok" || op.Service != "test" || op.Attempt != 1 {
+		t.Errorf("output %q service %q attempt %d", op.Output, op.Service, op.Attempt)
+	}
+	if got := strings.Join(calls, ","); got != "build,sign,send,unmarshal" {
+		t.Errorf("calls %s", got)
+	}
+}
+
+func TestClientFaultInjection(t *testing.T) {
+	requests := 0
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	})
+	injected := errors.New("injected")
+	client.Handlers.Add(SendPhase, "fault", func(next Handler) Handler {
+		return func(op *Operation) error {
+			if op.Attempt == 1 {
+				return &net503{injected}
+			}
+			return next(op)
+		}
+	})
+	op := testOperation(client)
+	if err := client.Run(op); err != nil {
+		t.Fatal(err)
+	}
+	if op.Attempt != 2 || requests != 1 {
+		t.Errorf("attempt %d requests %d", op.Attempt, requests)
+	}
+
+	client.Handlers.Add(SendPhase, "fault", func(next Handler) Handler {
+		return func(op *Operation) error { return injected }
+	})
+	copied := client.Handlers.Copy()
+	copied.Remove(SendPhase, "fault")
+	err := client.Run(testOperation(client))
+	if !errors.Is(err, injected) {
+		t.Errorf("error %v", err)
+	}
+	client.Handlers = copied
+	if err := client.Run(testOperation(client)); err != nil {
+		t.Errorf("copied handlers: %v", err)
+	}
+}
+
+// A retryable error.
+type net503 struct{ err error }
+
+func (err *net503) Error() string     { return err.err.Error() }
+func (err *net503) ErrorCode() string { return "ServiceUnavailable" }
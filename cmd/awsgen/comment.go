@@ -0,0 +1,84 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// The width comments are wrapped to, not counting indentation.
+const commentWidth = 76
+
+var (
+	htmlFullname  = regexp.MustCompile(`(?is)<fullname>.*?</fullname>`)
+	htmlParagraph = regexp.MustCompile(`(?i)</?p>|<br\s*/?>|</?ul>|</?ol>|</li>`)
+	htmlListItem  = regexp.MustCompile(`(?i)<li>(\s*<p>)?`)
+	htmlTag       = regexp.MustCompile(`<[^>]*>`)
+	whitespace    = regexp.MustCompile(`\s+`)
+)
+
+// docParagraphs converts the HTML documentation of a model to paragraphs of
+// plain text. List items become paragraphs starting with "- ".
+func docParagraphs(doc string) []string {
+	doc = htmlFullname.ReplaceAllString(doc, "")
+	doc = htmlListItem.ReplaceAllString(doc, "\x00- ")
+	doc = htmlParagraph.ReplaceAllString(doc, "\x00")
+	doc = htmlTag.ReplaceAllString(doc, "")
+	var paragraphs []string
+	for _, p := range strings.Split(doc, "\x00") {
+		p = strings.TrimSpace(whitespace.ReplaceAllString(html.UnescapeString(p), " "))
+		if p != "" && p != "-" {
+			paragraphs = append(paragraphs, p)
+		}
+	}
+	return paragraphs
+}
+
+// comment returns paragraphs as a Go comment, with each line indented by
+// indent. Paragraphs are wrapped and separated by empty comment lines.
+func comment(indent string, paragraphs ...string) string {
+	var lines []string
+	for i, p := range paragraphs {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		if !strings.HasPrefix(p, "- ") {
+			lines = append(lines, wrap(p, commentWidth-len(indent))...)
+			continue
+		}
+		for j, line := range wrap(p[2:], commentWidth-len(indent)-4) {
+			if j == 0 {
+				lines = append(lines, "  - "+line)
+			} else {
+				lines = append(lines, "    "+line)
+			}
+		}
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(indent)
+		b.WriteString(strings.TrimRight("// "+line, " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func wrap(text string, width int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,950 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Generate returns the source of package pkg, a client for the service
+// described by model. Paginators and waiters which cannot be generated are
+// skipped and described by the returned warnings.
+func Generate(model *Model, pkg, source string) ([]byte, []string, error) {
+	g := &generator{
+		model:    model,
+		pkg:      pkg,
+		source:   source,
+		imports:  make(map[string]bool),
+		names:    make(map[string]string),
+		refs:     make(map[string]int),
+		outputs:  make(map[string]*ShapeRef),
+		reserved: make(map[string]bool),
+	}
+	switch model.Metadata.Protocol {
+	case "json", "query":
+	default:
+		return nil, nil, fmt.Errorf("unsupported protocol %q", model.Metadata.Protocol)
+	}
+	if err := g.prepare(); err != nil {
+		return nil, nil, err
+	}
+	body, err := g.generate()
+	if err != nil {
+		return nil, nil, err
+	}
+	src, err := format.Source(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generated invalid source: %v\n%s", err, body)
+	}
+	return src, g.warnings, nil
+}
+
+type generator struct {
+	model  *Model
+	pkg    string
+	source string
+
+	ops       []*Operation
+	types     []string          // shapes with generated types, sorted
+	errors    []string          // error shapes, sorted
+	imports   map[string]bool   // packages used by the generated code
+	names     map[string]string // package level identifiers and what declared them
+	refs      map[string]int    // references to shapes from members
+	outputs   map[string]*ShapeRef
+	reserved  map[string]bool // output shapes of query operations
+	warnings  []string
+	paginated map[string]*Paginator
+	waiters   map[string][]string // operation names to waiter names
+
+	buf bytes.Buffer
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) warnf(format string, args ...interface{}) {
+	g.warnings = append(g.warnings, fmt.Sprintf(format, args...))
+}
+
+func (g *generator) isQuery() bool {
+	return g.model.Metadata.Protocol == "query"
+}
+
+func (g *generator) signingName() string {
+	if g.model.Metadata.SigningName != "" {
+		return g.model.Metadata.SigningName
+	}
+	return g.model.Metadata.EndpointPrefix
+}
+
+func (g *generator) shape(name string) (*Shape, error) {
+	shape := g.model.Shapes[name]
+	if shape == nil {
+		return nil, fmt.Errorf("undefined shape %q", name)
+	}
+	return shape, nil
+}
+
+// declare reserves a package level identifier.
+func (g *generator) declare(name, what string) error {
+	if prev, ok := g.names[name]; ok {
+		return fmt.Errorf("%s and %s are both named %s", prev, what, name)
+	}
+	g.names[name] = what
+	return nil
+}
+
+// Finds the operations and the shapes they use, and checks that their
+// names do not conflict.
+func (g *generator) prepare() error {
+	for _, name := range []string{"Version", "Region", "Client", "NewClient", "Request", "Error"} {
+		g.declare(name, "generated code")
+	}
+	var opNames []string
+	for name := range g.model.Operations {
+		opNames = append(opNames, name)
+	}
+	sort.Strings(opNames)
+
+	used := make(map[string]bool)
+	errors := make(map[string]bool)
+	var walk func(name string) error
+	walk = func(name string) error {
+		if used[name] {
+			return nil
+		}
+		used[name] = true
+		shape, err := g.shape(name)
+		if err != nil {
+			return err
+		}
+		var refs []*ShapeRef
+		switch shape.Type {
+		case "structure":
+			for _, member := range shape.Members {
+				refs = append(refs, member.ShapeRef)
+			}
+		case "list":
+			refs = append(refs, shape.Member)
+		case "map":
+			refs = append(refs, shape.Key, shape.Value)
+		}
+		for _, ref := range refs {
+			g.refs[ref.Shape]++
+			if err := walk(ref.Shape); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, name := range opNames {
+		op := g.model.Operations[name]
+		if op.HTTP.Method != "" && op.HTTP.Method != "POST" {
+			return fmt.Errorf("operation %s: unsupported method %s", name, op.HTTP.Method)
+		}
+		g.ops = append(g.ops, op)
+		if err := g.declare(name, "operation "+name); err != nil {
+			return err
+		}
+		for _, ref := range []*ShapeRef{op.Input, op.Output} {
+			if ref != nil {
+				if err := walk(ref.Shape); err != nil {
+					return err
+				}
+			}
+		}
+		if op.Input != nil {
+			g.refs[op.Input.Shape]++
+		}
+		if op.Output != nil {
+			g.refs[op.Output.Shape]++
+			g.outputs[name] = op.Output
+			if g.isQuery() {
+				g.reserved[op.Output.Shape] = true
+			}
+		}
+		for _, ref := range op.Errors {
+			errors[ref.Shape] = true
+		}
+	}
+	for name := range g.reserved {
+		if g.refs[name] > 1 {
+			return fmt.Errorf("shape %s: query output shapes may not be used elsewhere", name)
+		}
+	}
+
+	for name := range used {
+		shape := g.model.Shapes[name]
+		switch {
+		case shape.Type == "structure":
+		case shape.Type == "string" && len(shape.Enum) > 0:
+		case shape.Type == "map" && g.isQuery():
+		default:
+			continue
+		}
+		g.types = append(g.types, name)
+	}
+	sort.Strings(g.types)
+	for _, name := range g.types {
+		if err := g.declare(exported(name), "shape "+name); err != nil {
+			return err
+		}
+		shape := g.model.Shapes[name]
+		for _, value := range shape.Enum {
+			if err := g.declare(enumConst(name, value), "value "+value+" of "+name); err != nil {
+				return err
+			}
+		}
+	}
+	for name := range errors {
+		g.errors = append(g.errors, name)
+	}
+	sort.Strings(g.errors)
+	for _, name := range g.errors {
+		if _, err := g.shape(name); err != nil {
+			return err
+		}
+		if err := g.declare(exported(name), "error "+name); err != nil {
+			return err
+		}
+	}
+
+	g.paginated = make(map[string]*Paginator)
+	for name, p := range g.model.Paginators {
+		if err := g.checkPaginator(name, p); err != nil {
+			g.warnf("skipped paginator %s: %v", name, err)
+			continue
+		}
+		g.paginated[name] = p
+	}
+	g.waiters = make(map[string][]string)
+	var waiterNames []string
+	for name := range g.model.Waiters {
+		waiterNames = append(waiterNames, name)
+	}
+	sort.Strings(waiterNames)
+	for _, name := range waiterNames {
+		if err := g.checkWaiter(g.model.Waiters[name]); err != nil {
+			g.warnf("skipped waiter %s: %v", name, err)
+			continue
+		}
+		op := g.model.Waiters[name].Operation
+		g.waiters[op] = append(g.waiters[op], name)
+	}
+	sort.Strings(g.warnings)
+	return nil
+}
+
+func (g *generator) checkPaginator(name string, p *Paginator) error {
+	op := g.model.Operations[name]
+	if op == nil {
+		return fmt.Errorf("no operation %s", name)
+	}
+	if op.Input == nil || op.Output == nil {
+		return fmt.Errorf("operation %s has no input or output", name)
+	}
+	if len(p.InputToken) != len(p.OutputToken) || len(p.InputToken) == 0 {
+		return fmt.Errorf("input and output tokens do not correspond")
+	}
+	input := g.model.Shapes[op.Input.Shape]
+	output := g.model.Shapes[op.Output.Shape]
+	for i := range p.InputToken {
+		in := input.Members.Get(p.InputToken[i])
+		out := output.Members.Get(p.OutputToken[i])
+		if in == nil || out == nil {
+			return fmt.Errorf("unsupported tokens %s and %s", p.InputToken[i], p.OutputToken[i])
+		}
+		if g.fieldType(in.ShapeRef) != g.fieldType(out.ShapeRef) {
+			return fmt.Errorf("tokens %s and %s have different types", in.Name, out.Name)
+		}
+	}
+	if p.MoreResults != "" {
+		more := output.Members.Get(p.MoreResults)
+		if more == nil || g.model.Shapes[more.Shape].Type != "boolean" {
+			return fmt.Errorf("unsupported more_results %s", p.MoreResults)
+		}
+	}
+	return nil
+}
+
+var waiterPath = regexp.MustCompile(`^[A-Za-z0-9_]+(\[\])?(\.[A-Za-z0-9_]+(\[\])?)*$`)
+
+func (g *generator) checkWaiter(w *Waiter) error {
+	if g.model.Operations[w.Operation] == nil {
+		return fmt.Errorf("no operation %s", w.Operation)
+	}
+	for _, a := range w.Acceptors {
+		if waiterStates[a.State] == "" {
+			return fmt.Errorf("unsupported state %q", a.State)
+		}
+		switch a.Matcher {
+		case "path", "pathAll", "pathAny":
+			if !waiterPath.MatchString(a.Argument) {
+				return fmt.Errorf("unsupported argument %q", a.Argument)
+			}
+		case "status", "error":
+		default:
+			return fmt.Errorf("unsupported matcher %q", a.Matcher)
+		}
+		switch a.Expected.(type) {
+		case string, float64, bool:
+		default:
+			return fmt.Errorf("unsupported expected value %v", a.Expected)
+		}
+	}
+	return nil
+}
+
+var waiterStates = map[string]string{
+	"success": "aws.WaiterSuccess",
+	"failure": "aws.WaiterFailure",
+	"retry":   "aws.WaiterRetry",
+}
+
+var waiterMatchers = map[string]string{
+	"path":    "aws.MatchPath",
+	"pathAll": "aws.MatchPathAll",
+	"pathAny": "aws.MatchPathAny",
+	"status":  "aws.MatchStatus",
+	"error":   "aws.MatchError",
+}
+
+func (g *generator) generate() ([]byte, error) {
+	meta := g.model.Metadata
+	service := meta.ServiceFullName
+	if service == "" {
+		service = meta.ServiceID
+	}
+
+	g.printf("// Code generated by awsgen from %s. DO NOT EDIT.\n\n", g.source)
+	doc := []string{fmt.Sprintf("Package %s is a client for %s.", g.pkg, service)}
+	if paragraphs := docParagraphs(g.model.Documentation); len(paragraphs) > 0 {
+		doc = append(doc, paragraphs[0])
+	}
+	g.printf("%spackage %s\n\n", comment("", doc...), g.pkg)
+	g.printf("import (\n%%IMPORTS%%)\n\n")
+
+	g.imports["github.com/bmatsuo/go-aws"] = true
+	g.imports["net/http"] = true
+	g.printf("// The API version of the service model.\nconst Version = %q\n\n", meta.APIVersion)
+	g.printf("const signingName = %q\n\n", g.signingName())
+	if !g.isQuery() {
+		g.printf("const (\n\ttargetPrefix = %q\n\tjsonVersion = %q\n)\n\n", meta.TargetPrefix, meta.JSONVersion)
+	}
+	g.printf("%s", comment("", fmt.Sprintf(`Region returns the %s endpoint for the named region (e.g. "us-west-2").`, service)))
+	g.printf("func Region(name string) (*aws.Region, error) {\n\treturn aws.ResolveEndpoint(%q, name)\n}\n\n", meta.EndpointPrefix)
+	g.printf(`// Client runs each API call through the phases of the embedded
+// *aws.Client, whose Handlers may be used to customize requests.
+type Client struct {
+	*aws.Client
+}
+
+// Credentials are retrieved from creds each time a request is signed. A
+// *aws.Credentials may be given for static keys.
+func NewClient(creds aws.CredentialsProvider, region *aws.Region) *Client {
+`)
+	if g.isQuery() {
+		g.printf("\tclient := aws.NewClient(signingName, creds, region)\n")
+		g.printf("\tclient.ParseError = aws.QueryErrorParser(signingName)\n")
+		g.printf("\treturn &Client{client}\n}\n\n")
+	} else {
+		g.printf("\tclient := aws.NewJSONClient(signingName, targetPrefix, jsonVersion, creds, region)\n")
+		g.printf("\treturn &Client{client.Client}\n}\n\n")
+	}
+	errorType, decode := "aws.JSONError", "aws.DecodeJSONResponse"
+	if g.isQuery() {
+		errorType, decode = "aws.QueryError", "aws.DecodeQueryResponse"
+	}
+	g.imports["context"] = true
+	g.printf(`type Request interface {
+	Request(*aws.Region) (*http.Request, error)
+}
+
+// Error is returned for responses with a status other than 2xx.
+type Error = %s
+
+// Creates a signed request from req
+func (client *Client) Request(req Request) (*http.Request, error) {
+	hreq, err := req.Request(client.Region)
+	if err != nil {
+		return nil, err
+	}
+	err = client.Sign(hreq)
+	if err != nil {
+		return nil, err
+	}
+	return hreq, nil
+}
+
+func (client *Client) Do(req Request) (*http.Response, error) {
+	return client.DoContext(context.Background(), req)
+}
+
+// DoContext sends req, retrying failures according to client.Retryer. The
+// request is rebuilt and signed again for each attempt. Canceling ctx aborts
+// the request and any retries.
+func (client *Client) DoContext(ctx context.Context, req Request) (*http.Response, error) {
+	return client.run(ctx, req, nil)
+}
+
+// Sends req and decodes the response into output, which may be nil.
+func (client *Client) run(ctx context.Context, req Request, output interface{}) (*http.Response, error) {
+	op := &aws.Operation{
+		Context: ctx,
+		Name:    aws.OperationName(req),
+		Output:  output,
+		Build: func(op *aws.Operation) (err error) {
+			op.Request, err = req.Request(client.Region)
+			return err
+		},
+	}
+	if output != nil {
+		op.Unmarshal = func(op *aws.Operation) error {
+			return %s(op.Response, op.Output)
+		}
+	}
+	err := client.Run(op)
+	return op.Response, err
+}
+
+`, errorType, decode)
+
+	if len(g.errors) > 0 {
+		g.printf("%sconst (\n", comment("", fmt.Sprintf("Error codes returned by %s. Errors match their code with errors.Is.", service)))
+		for _, name := range g.errors {
+			code := name
+			if shape := g.model.Shapes[name]; shape.Error != nil && shape.Error.Code != "" {
+				code = shape.Error.Code
+			}
+			g.printf("\t%s aws.Code = %q\n", exported(name), code)
+		}
+		g.printf(")\n\n")
+	}
+
+	for _, op := range g.ops {
+		if err := g.operation(op); err != nil {
+			return nil, fmt.Errorf("operation %s: %v", op.Name, err)
+		}
+	}
+	for _, name := range g.types {
+		if err := g.shapeType(name); err != nil {
+			return nil, fmt.Errorf("shape %s: %v", name, err)
+		}
+	}
+
+	var imports []string
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	var importDecl strings.Builder
+	for _, path := range imports {
+		if path == "github.com/bmatsuo/go-aws" {
+			continue
+		}
+		fmt.Fprintf(&importDecl, "\t%q\n", path)
+	}
+	fmt.Fprintf(&importDecl, "\n\t%q\n", "github.com/bmatsuo/go-aws")
+	return bytes.Replace(g.buf.Bytes(), []byte("%IMPORTS%"), []byte(importDecl.String()), 1), nil
+}
+
+func (g *generator) operation(op *Operation) error {
+	name := op.Name
+	doc := []string{fmt.Sprintf("%s is a request for the %s API call.", name, name)}
+	doc = append(doc, docParagraphs(op.Documentation)...)
+	if op.Deprecated {
+		doc = append(doc, "Deprecated: "+name+" is deprecated.")
+	}
+	g.printf("%s", comment("", doc...))
+	g.printf("type %s struct {\n\tclient *Client\n", name)
+	var input *Shape
+	if op.Input != nil {
+		input = g.model.Shapes[op.Input.Shape]
+		g.printf("\tinput %s\n", exported(op.Input.Shape))
+	}
+	g.printf("}\n\n")
+
+	// Required members are arguments of the constructor and the others
+	// are set by methods of the request.
+	required := make(map[string]bool)
+	var params, assigns []string
+	methods := map[string]bool{"Exec": true, "ExecContext": true, "Request": true, "Pages": true, "PagesContext": true}
+	for _, waiter := range g.waiters[name] {
+		methods["WaitUntil"+waiter] = true
+	}
+	if input != nil {
+		for _, member := range input.Members {
+			if !contains(input.Required, member.Name) {
+				continue
+			}
+			required[member.Name] = true
+			param := paramName(member.Name, "client", "request")
+			typ, value := g.setterType(member.ShapeRef, param)
+			if strings.HasPrefix(typ, "...") {
+				typ = "[]" + typ[3:]
+			}
+			params = append(params, param+" "+typ)
+			assigns = append(assigns, fmt.Sprintf("\trequest.input.%s = %s\n", exported(member.Name), value))
+		}
+	}
+	g.printf("// %s returns a request for the %s API call.\n", name, name)
+	g.printf("func (client *Client) %s(%s) *%s {\n", name, strings.Join(params, ", "), name)
+	g.printf("\trequest := &%s{client: client}\n%s\treturn request\n}\n\n", name, strings.Join(assigns, ""))
+
+	result := "error"
+	if op.Output != nil {
+		result = fmt.Sprintf("(*%s, error)", exported(op.Output.Shape))
+	}
+	g.printf("func (request *%s) Exec() %s {\n\treturn request.ExecContext(context.Background())\n}\n\n", name, result)
+	g.printf("func (request *%s) ExecContext(ctx context.Context) %s {\n", name, result)
+	if op.Output != nil {
+		g.printf("\toutput := new(%s)\n", exported(op.Output.Shape))
+		g.printf("\t_, err := request.client.run(ctx, request, output)\n")
+		g.printf("\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn output, nil\n}\n\n")
+	} else {
+		g.printf("\tresp, err := request.client.run(ctx, request, nil)\n")
+		g.printf("\tif err != nil {\n\t\treturn err\n\t}\n\treturn resp.Body.Close()\n}\n\n")
+	}
+
+	g.printf("func (request *%s) Request(region *aws.Region) (*http.Request, error) {\n", name)
+	if g.isQuery() {
+		g.imports["net/url"] = true
+		g.printf("\tparams := url.Values{\"Action\": {%q}, \"Version\": {Version}}\n", name)
+		if input != nil {
+			g.printf("\tif err := aws.EncodeQuery(params, &request.input); err != nil {\n\t\treturn nil, err\n\t}\n")
+		}
+		g.printf("\treturn aws.NewQueryRequest(region, params)\n}\n\n")
+	} else if input != nil {
+		g.imports["encoding/json"] = true
+		g.printf("\tbody, err := json.Marshal(&request.input)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+		g.printf("\treturn aws.NewJSONRequest(region, targetPrefix+%q, jsonVersion, body)\n}\n\n", "."+name)
+	} else {
+		g.printf("\treturn aws.NewJSONRequest(region, targetPrefix+%q, jsonVersion, []byte(\"{}\"))\n}\n\n", "."+name)
+	}
+
+	if input != nil {
+		for _, member := range input.Members {
+			if required[member.Name] {
+				continue
+			}
+			method := exported(member.Name)
+			if methods[method] {
+				method = "Set" + method
+			}
+			methods[method] = true
+			param := paramName(member.Name, "request")
+			typ, value := g.setterType(member.ShapeRef, param)
+			g.printf("%s", comment("", g.memberDoc(member)...))
+			g.printf("func (request *%s) %s(%s %s) *%s {\n", name, method, param, typ, name)
+			g.printf("\trequest.input.%s = %s\n\treturn request\n}\n\n", exported(member.Name), value)
+		}
+	}
+
+	if p := g.paginated[name]; p != nil {
+		g.pages(op, p)
+	}
+	for _, waiter := range g.waiters[name] {
+		g.waiter(op, waiter, g.model.Waiters[waiter])
+	}
+	return nil
+}
+
+func (g *generator) pages(op *Operation, p *Paginator) {
+	name := op.Name
+	output := exported(op.Output.Shape)
+	outputShape := g.model.Shapes[op.Output.Shape]
+	g.printf("// Pages calls fn with each page of results until fn returns false or\n// there are no more pages.\n")
+	g.printf("func (request *%s) Pages(fn func(*%s) bool) error {\n", name, output)
+	g.printf("\treturn request.PagesContext(context.Background(), fn)\n}\n\n")
+	g.printf("func (request *%s) PagesContext(ctx context.Context, fn func(*%s) bool) error {\n", name, output)
+	g.printf("\tpage := *request\n\tfor {\n")
+	g.printf("\t\toutput, err := page.ExecContext(ctx)\n\t\tif err != nil {\n\t\t\treturn err\n\t\t}\n")
+	var last []string
+	if p.MoreResults != "" {
+		field := "output." + exported(p.MoreResults)
+		last = append(last, fmt.Sprintf("%s == nil || !*%s", field, field))
+	} else {
+		var empty []string
+		for _, token := range p.OutputToken {
+			member := outputShape.Members.Get(token)
+			field := "output." + exported(token)
+			switch typ := g.fieldType(member.ShapeRef); {
+			case strings.HasPrefix(typ, "*"):
+				empty = append(empty, field+" == nil")
+			case strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map["):
+				empty = append(empty, "len("+field+") == 0")
+			default:
+				empty = append(empty, field+" == \"\"")
+			}
+		}
+		last = append(last, strings.Join(empty, " && "))
+	}
+	g.printf("\t\tif !fn(output) || %s {\n\t\t\treturn nil\n\t\t}\n", strings.Join(last, " || "))
+	for i, token := range p.InputToken {
+		g.printf("\t\tpage.input.%s = output.%s\n", exported(token), exported(p.OutputToken[i]))
+	}
+	g.printf("\t}\n}\n\n")
+}
+
+func (g *generator) waiter(op *Operation, name string, w *Waiter) {
+	g.imports["time"] = true
+	method := "WaitUntil" + name
+	doc := fmt.Sprintf("%s calls %s until the %s waiter's conditions are met, polling every %ds up to %d times.",
+		method, op.Name, name, w.Delay, w.MaxAttempts)
+	g.printf("%s", comment("", doc))
+	g.printf("func (request *%s) %s(ctx context.Context) error {\n", op.Name, method)
+	g.printf("\twaiter := &aws.Waiter{\n\t\tName: %q,\n\t\tDelay: %d * time.Second,\n\t\tMaxAttempts: %d,\n", name, w.Delay, w.MaxAttempts)
+	g.printf("\t\tAcceptors: []aws.WaiterAcceptor{\n")
+	for _, a := range w.Acceptors {
+		g.printf("\t\t\t{State: %s, Matcher: %s", waiterStates[a.State], waiterMatchers[a.Matcher])
+		if a.Argument != "" {
+			parts := strings.Split(a.Argument, ".")
+			for i, part := range parts {
+				parts[i] = exported(part)
+			}
+			g.printf(", Argument: %q", strings.Join(parts, "."))
+		}
+		g.printf(", Expected: %s},\n", literal(a.Expected))
+	}
+	g.printf("\t\t},\n\t}\n")
+	g.printf("\treturn waiter.Wait(ctx, func(ctx context.Context) (interface{}, error) {\n")
+	if op.Output != nil {
+		g.printf("\t\treturn request.ExecContext(ctx)\n")
+	} else {
+		g.printf("\t\treturn nil, request.ExecContext(ctx)\n")
+	}
+	g.printf("\t})\n}\n\n")
+}
+
+func literal(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func (g *generator) memberDoc(member *Member) []string {
+	doc := docParagraphs(member.Documentation)
+	if len(doc) == 0 {
+		if shape := g.model.Shapes[member.Shape]; shape != nil {
+			doc = docParagraphs(shape.Documentation)
+		}
+	}
+	if member.Deprecated {
+		doc = append(doc, "Deprecated: "+member.Name+" is deprecated.")
+	}
+	return doc
+}
+
+// setterType returns the parameter type of a setter for ref, and the value
+// assigned from the parameter.
+func (g *generator) setterType(ref *ShapeRef, param string) (typ, value string) {
+	field := g.fieldType(ref)
+	shape := g.model.Shapes[ref.Shape]
+	switch {
+	case shape.Type == "structure":
+		return field, param
+	case strings.HasPrefix(field, "*"):
+		return field[1:], "&" + param
+	case shape.Type == "list":
+		return "..." + strings.TrimPrefix(field, "[]"), param
+	}
+	return field, param
+}
+
+// Shapes of these types are pointers in structure fields, so their zero
+// values may be sent.
+var pointerTypes = map[string]bool{
+	"structure": true,
+	"integer":   true,
+	"long":      true,
+	"boolean":   true,
+	"double":    true,
+	"float":     true,
+	"timestamp": true,
+}
+
+func (g *generator) fieldType(ref *ShapeRef) string {
+	typ := g.valueType(ref)
+	if pointerTypes[g.model.Shapes[ref.Shape].Type] {
+		return "*" + typ
+	}
+	return typ
+}
+
+func (g *generator) valueType(ref *ShapeRef) string {
+	shape := g.model.Shapes[ref.Shape]
+	switch shape.Type {
+	case "structure":
+		return exported(ref.Shape)
+	case "list":
+		return "[]" + g.valueType(shape.Member)
+	case "map":
+		if g.isQuery() {
+			return exported(ref.Shape)
+		}
+		return "map[" + g.valueType(shape.Key) + "]" + g.valueType(shape.Value)
+	case "string":
+		if len(shape.Enum) > 0 {
+			return exported(ref.Shape)
+		}
+		return "string"
+	case "integer":
+		return "int"
+	case "long":
+		return "int64"
+	case "boolean":
+		return "bool"
+	case "double", "float":
+		return "float64"
+	case "blob":
+		return "[]byte"
+	case "timestamp":
+		format := ref.TimestampFormat
+		if format == "" {
+			format = shape.TimestampFormat
+		}
+		if g.isQuery() || format == "iso8601" {
+			g.imports["time"] = true
+			return "time.Time"
+		}
+		return "aws.UnixTime"
+	}
+	return "interface{}"
+}
+
+func (g *generator) shapeType(name string) error {
+	shape := g.model.Shapes[name]
+	typ := exported(name)
+	doc := docParagraphs(shape.Documentation)
+	if len(doc) == 0 {
+		doc = []string{g.shapeDoc(name)}
+	}
+	g.printf("%s", comment("", doc...))
+	switch shape.Type {
+	case "string":
+		g.printf("type %s string\n\n// Values of %s.\nconst (\n", typ, typ)
+		for _, value := range shape.Enum {
+			g.printf("\t%s %s = %q\n", enumConst(name, value), typ, value)
+		}
+		g.printf(")\n\n")
+	case "map":
+		return g.queryMap(name, shape)
+	case "structure":
+		g.printf("type %s struct {\n", typ)
+		for i, member := range shape.Members {
+			if err := g.field(member, i == 0); err != nil {
+				return fmt.Errorf("member %s: %v", member.Name, err)
+			}
+		}
+		if g.reserved[name] {
+			g.printf("\n\taws.ResponseMetadata\n")
+		}
+		g.printf("}\n\n")
+	}
+	return nil
+}
+
+// The documentation of shapes without any in the model.
+func (g *generator) shapeDoc(name string) string {
+	for _, op := range g.ops {
+		switch {
+		case op.Input != nil && op.Input.Shape == name:
+			return fmt.Sprintf("%s is the input of %s.", exported(name), op.Name)
+		case op.Output != nil && op.Output.Shape == name:
+			return fmt.Sprintf("%s is the output of %s.", exported(name), op.Name)
+		}
+	}
+	if len(g.model.Shapes[name].Enum) > 0 {
+		return fmt.Sprintf("%s is a string with one of the values below.", exported(name))
+	}
+	return fmt.Sprintf("%s is the %s shape of the service model.", exported(name), name)
+}
+
+func (g *generator) field(member *Member, first bool) error {
+	shape, err := g.shape(member.Shape)
+	if err != nil {
+		return err
+	}
+	if g.valueType(member.ShapeRef) == "interface{}" {
+		return fmt.Errorf("unsupported type %q", shape.Type)
+	}
+	if shape.Type == "timestamp" && g.isQuery() && member.TimestampFormat != "" && member.TimestampFormat != "iso8601" {
+		return fmt.Errorf("unsupported timestamp format %q", member.TimestampFormat)
+	}
+	name := member.Name
+	if member.LocationName != "" {
+		name = member.LocationName
+	}
+	var tag string
+	if g.isQuery() {
+		tag = g.queryTags(member, name)
+	} else {
+		tag = fmt.Sprintf(`json:"%s,omitempty"`, name)
+	}
+	if doc := g.memberDoc(member); len(doc) > 0 {
+		if !first {
+			g.printf("\n")
+		}
+		g.printf("%s", comment("\t", doc...))
+	}
+	g.printf("\t%s %s `%s`\n", exported(member.Name), g.fieldType(member.ShapeRef), tag)
+	return nil
+}
+
+// The query and xml tags of a member of a query protocol shape. Members of
+// output shapes are wrapped in the operation's result element.
+func (g *generator) queryTags(member *Member, name string) string {
+	shape := g.model.Shapes[member.Shape]
+	if shape.Type == "list" && shape.Flattened && shape.Member.LocationName != "" {
+		// The items of flattened lists are named after the list's member.
+		name = shape.Member.LocationName
+	}
+	query := []string{name}
+	xmlPath := name
+	switch shape.Type {
+	case "list":
+		if shape.Flattened {
+			query = append(query, "flattened")
+		} else {
+			item := "member"
+			if shape.Member.LocationName != "" {
+				item = shape.Member.LocationName
+			}
+			xmlPath += ">" + item
+		}
+	case "map":
+		if shape.Flattened {
+			query = append(query, "flattened")
+		}
+		key, value := mapNames(shape)
+		if key != "key" || value != "value" {
+			query = append(query, "key="+key, "value="+value)
+		}
+	}
+	if wrapper := g.resultWrapper(member); wrapper != "" {
+		xmlPath = wrapper + ">" + xmlPath
+	}
+	return fmt.Sprintf(`query:"%s" xml:"%s"`, strings.Join(query, ","), xmlPath)
+}
+
+// The result wrapper of the operation with member in its output.
+func (g *generator) resultWrapper(member *Member) string {
+	for _, ref := range g.outputs {
+		if shape := g.model.Shapes[ref.Shape]; shape.Members.Get(member.Name) == member {
+			return ref.ResultWrapper
+		}
+	}
+	return ""
+}
+
+func mapNames(shape *Shape) (key, value string) {
+	key, value = "key", "value"
+	if shape.Key.LocationName != "" {
+		key = shape.Key.LocationName
+	}
+	if shape.Value.LocationName != "" {
+		value = shape.Value.LocationName
+	}
+	return key, value
+}
+
+// Query protocol maps are named types which decode the XML entries of a
+// map.
+func (g *generator) queryMap(name string, shape *Shape) error {
+	g.imports["encoding/xml"] = true
+	typ := exported(name)
+	keyType, valueType := g.valueType(shape.Key), g.valueType(shape.Value)
+	key, value := mapNames(shape)
+	g.printf("type %s map[%s]%s\n\n", typ, keyType, valueType)
+	g.printf("func (m *%s) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {\n", typ)
+	g.printf("\ttype entry struct {\n\t\tKey %s `xml:\"%s\"`\n\t\tValue %s `xml:\"%s\"`\n\t}\n", keyType, key, valueType, value)
+	g.printf("\tif *m == nil {\n\t\t*m = make(%s)\n\t}\n", typ)
+	if shape.Flattened {
+		g.printf("\tvar e entry\n\tif err := d.DecodeElement(&e, &start); err != nil {\n\t\treturn err\n\t}\n")
+		g.printf("\t(*m)[e.Key] = e.Value\n\treturn nil\n}\n\n")
+	} else {
+		g.printf("\tvar entries struct {\n\t\tEntries []entry `xml:\"entry\"`\n\t}\n")
+		g.printf("\tif err := d.DecodeElement(&entries, &start); err != nil {\n\t\treturn err\n\t}\n")
+		g.printf("\tfor _, e := range entries.Entries {\n\t\t(*m)[e.Key] = e.Value\n\t}\n\treturn nil\n}\n\n")
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// exported returns name with its first letter in upper case.
+func exported(name string) string {
+	r := []rune(name)
+	if len(r) == 0 {
+		return name
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// enumConst returns the name of the constant for a value of an enum shape
+// (e.g. TableStatusActive for "ACTIVE").
+func enumConst(shape, value string) string {
+	name := exported(shape)
+	parts := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, part := range parts {
+		if strings.ToUpper(part) == part {
+			part = strings.ToLower(part)
+		}
+		name += exported(part)
+	}
+	return name
+}
+
+var keywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+}
+
+// paramName returns a parameter name for a member, which is not a keyword
+// or one of the reserved names (e.g. tableName for TableName and
+// sseSpecification for SSESpecification).
+func paramName(member string, reserved ...string) string {
+	r := []rune(member)
+	i := 0
+	for i < len(r) && unicode.IsUpper(r[i]) {
+		i++
+	}
+	if i > 1 && i < len(r) {
+		i-- // the last upper case letter starts the next word
+	}
+	if i == 0 {
+		i = 1
+	}
+	name := strings.ToLower(string(r[:i])) + string(r[i:])
+	if keywords[name] || contains(reserved, name) {
+		name += "Value"
+	}
+	return name
+}
@@ -0,0 +1,142 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func generateDir(t *testing.T, dir, pkg string) ([]byte, []string) {
+	model, err := LoadModel(
+		filepath.Join(dir, "api-2.json"),
+		filepath.Join(dir, "paginators-1.json"),
+		filepath.Join(dir, "waiters-2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, warnings, err := Generate(model, pkg, "api-2.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return src, warnings
+}
+
+func TestGenerateGolden(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		src, _ := generateDir(t, dir, filepath.Base(dir))
+		golden := filepath.Join(dir, "api.go.golden")
+		if *update {
+			if err := ioutil.WriteFile(golden, src, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(src, want) {
+			t.Errorf("%s: output differs from %s (run go test -update)", dir, golden)
+		}
+	}
+}
+
+// The generated dynamodb package must be regenerated when the generator
+// changes.
+func TestGenerateDynamoDB(t *testing.T) {
+	dir := filepath.Join("..", "..", "exp", "dynamodb")
+	src, warnings := generateDir(t, dir, "dynamodb")
+	if len(warnings) > 0 {
+		t.Errorf("warnings: %q", warnings)
+	}
+	want, err := ioutil.ReadFile(filepath.Join(dir, "api.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("exp/dynamodb/api.go is out of date (run go generate)")
+	}
+}
+
+func TestGenerateWarnings(t *testing.T) {
+	_, warnings := generateDir(t, filepath.Join("testdata", "sqs"), "sqs")
+	want := []string{
+		"skipped paginator ListDeadLetterSourceQueues: no operation ListDeadLetterSourceQueues",
+		"skipped waiter MessageAvailable: unsupported argument \"length(Messages[]) > `0`\"",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings: %q (!= %q)", warnings, want)
+	}
+}
+
+func TestGenerateProtocol(t *testing.T) {
+	model := &Model{Metadata: Metadata{Protocol: "rest-xml"}}
+	_, _, err := Generate(model, "s3", "api-2.json")
+	if err == nil || !strings.Contains(err.Error(), "rest-xml") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDocParagraphs(t *testing.T) {
+	doc := `<fullname>Amazon Thing</fullname> <p>Does <code>things</code> &amp; more.</p>` +
+		`<ul><li><p>One</p></li> <li>Two</li></ul>`
+	want := []string{"Does things & more.", "- One", "- Two"}
+	if p := docParagraphs(doc); !reflect.DeepEqual(p, want) {
+		t.Errorf("paragraphs: %q (!= %q)", p, want)
+	}
+}
+
+func TestComment(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	got := comment("\t", "Short.", "- "+long)
+	want := "\t// Short.\n" +
+		"\t//\n" +
+		"\t//   - word word word word word word word word word word word word word word\n" +
+		"\t//     word word word word word word\n"
+	if got != want {
+		t.Errorf("comment:\n%s\n(!=)\n%s", got, want)
+	}
+}
+
+func TestEnumConst(t *testing.T) {
+	for _, test := range []struct{ shape, value, want string }{
+		{"TableStatus", "ACTIVE", "TableStatusActive"},
+		{"KeyType", "HASH", "KeyTypeHash"},
+		{"Select", "ALL_ATTRIBUTES", "SelectAllAttributes"},
+		{"QueueAttributeName", "VisibilityTimeout", "QueueAttributeNameVisibilityTimeout"},
+		{"instanceType", "t2.micro", "InstanceTypeT2Micro"},
+	} {
+		if name := enumConst(test.shape, test.value); name != test.want {
+			t.Errorf("enumConst(%q, %q): %q (!= %q)", test.shape, test.value, name, test.want)
+		}
+	}
+}
+
+func TestParamName(t *testing.T) {
+	for _, test := range []struct{ member, want string }{
+		{"TableName", "tableName"},
+		{"SSESpecification", "sseSpecification"},
+		{"QueueUrl", "queueUrl"},
+		{"ID", "id"},
+		{"Type", "typeValue"},
+		{"Client", "clientValue"},
+	} {
+		if name := paramName(test.member, "client"); name != test.want {
+			t.Errorf("paramName(%q): %q (!= %q)", test.member, name, test.want)
+		}
+	}
+}
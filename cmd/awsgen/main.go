@@ -0,0 +1,72 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Command awsgen generates a client package from an AWS service model, the
+api-2.json file distributed with the AWS SDKs, and optionally the model's
+paginator and waiter definitions. Services using the query and json
+protocols are supported.
+
+	awsgen -model api-2.json -paginators paginators-1.json -waiters waiters-2.json -o api.go
+
+Each API call gets a request type, constructed by a method of the package's
+*Client with the call's required members as arguments. Optional members are
+set by methods of the request, and Exec sends it. Use it from a go:generate
+directive in the package:
+
+	//go:generate go run ../../cmd/awsgen -model api-2.json -o api.go
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	modelPath := flag.String("model", "", "service model (api-2.json)")
+	paginatorsPath := flag.String("paginators", "", "paginator definitions (paginators-1.json)")
+	waitersPath := flag.String("waiters", "", "waiter definitions (waiters-2.json)")
+	pkg := flag.String("package", "", "package name (default: the output directory's name)")
+	out := flag.String("o", "", "output file (default: standard output)")
+	flag.Parse()
+	if *modelPath == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *pkg == "" {
+		dir, err := filepath.Abs(filepath.Dir(*out))
+		if err != nil {
+			fatal(err)
+		}
+		*pkg = filepath.Base(dir)
+	}
+	model, err := LoadModel(*modelPath, *paginatorsPath, *waitersPath)
+	if err != nil {
+		fatal(err)
+	}
+	src, warnings, err := Generate(model, *pkg, filepath.Base(*modelPath))
+	if err != nil {
+		fatal(err)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintln(os.Stderr, "awsgen:", warning)
+	}
+	if *out == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "awsgen:", err)
+	os.Exit(1)
+}
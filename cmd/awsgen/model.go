@@ -0,0 +1,198 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Model is an AWS service model definition, as distributed in the api-2.json
+// files of the AWS SDKs.
+type Model struct {
+	Metadata      Metadata
+	Operations    map[string]*Operation
+	Shapes        map[string]*Shape
+	Documentation string
+
+	Paginators map[string]*Paginator // from paginators-1.json
+	Waiters    map[string]*Waiter    // from waiters-2.json
+}
+
+type Metadata struct {
+	APIVersion      string `json:"apiVersion"`
+	EndpointPrefix  string `json:"endpointPrefix"`
+	JSONVersion     string `json:"jsonVersion"`
+	Protocol        string `json:"protocol"`
+	ServiceFullName string `json:"serviceFullName"`
+	ServiceID       string `json:"serviceId"`
+	SigningName     string `json:"signingName"`
+	TargetPrefix    string `json:"targetPrefix"`
+}
+
+type Operation struct {
+	Name string
+	HTTP struct {
+		Method     string
+		RequestURI string `json:"requestUri"`
+	}
+	Input         *ShapeRef
+	Output        *ShapeRef
+	Errors        []*ShapeRef
+	Documentation string
+	Deprecated    bool
+}
+
+// ShapeRef refers to a shape from an operation or another shape.
+type ShapeRef struct {
+	Shape           string
+	LocationName    string
+	ResultWrapper   string
+	TimestampFormat string
+	Documentation   string
+	Deprecated      bool
+}
+
+type Shape struct {
+	Type            string
+	Members         Members
+	Required        []string
+	Member          *ShapeRef // of a list
+	Key, Value      *ShapeRef // of a map
+	Enum            []string
+	Flattened       bool
+	Exception       bool
+	Error           *ShapeError
+	TimestampFormat string
+	Documentation   string
+	Deprecated      bool
+}
+
+type ShapeError struct {
+	Code           string
+	HTTPStatusCode int `json:"httpStatusCode"`
+}
+
+// Members are the members of a structure, in the order of the model.
+type Members []*Member
+
+type Member struct {
+	Name string
+	*ShapeRef
+}
+
+func (members *Members) UnmarshalJSON(p []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(p))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("members are not an object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		member := &Member{Name: tok.(string)}
+		if err := dec.Decode(&member.ShapeRef); err != nil {
+			return err
+		}
+		*members = append(*members, member)
+	}
+	return nil
+}
+
+func (members Members) Get(name string) *Member {
+	for _, member := range members {
+		if member.Name == name {
+			return member
+		}
+	}
+	return nil
+}
+
+// Paginator describes the tokens which page through the results of an
+// operation.
+type Paginator struct {
+	InputToken  Strings `json:"input_token"`
+	OutputToken Strings `json:"output_token"`
+	LimitKey    string  `json:"limit_key"`
+	MoreResults string  `json:"more_results"`
+	ResultKey   Strings `json:"result_key"`
+}
+
+// Strings is a string or a list of strings.
+type Strings []string
+
+func (s *Strings) UnmarshalJSON(p []byte) error {
+	var str string
+	if json.Unmarshal(p, &str) == nil {
+		*s = Strings{str}
+		return nil
+	}
+	return json.Unmarshal(p, (*[]string)(s))
+}
+
+// Waiter polls an operation until it is in a desired state.
+type Waiter struct {
+	Operation   string
+	Delay       int
+	MaxAttempts int `json:"maxAttempts"`
+	Description string
+	Acceptors   []*Acceptor
+}
+
+type Acceptor struct {
+	State    string
+	Matcher  string
+	Argument string
+	Expected interface{}
+}
+
+// LoadModel reads a service model and, when their paths are not empty, its
+// paginator and waiter definitions.
+func LoadModel(modelPath, paginatorsPath, waitersPath string) (*Model, error) {
+	model := new(Model)
+	if err := readJSON(modelPath, model); err != nil {
+		return nil, err
+	}
+	if paginatorsPath != "" {
+		var paginators struct{ Pagination map[string]*Paginator }
+		if err := readJSON(paginatorsPath, &paginators); err != nil {
+			return nil, err
+		}
+		model.Paginators = paginators.Pagination
+	}
+	if waitersPath != "" {
+		var waiters struct {
+			Version int
+			Waiters map[string]*Waiter
+		}
+		if err := readJSON(waitersPath, &waiters); err != nil {
+			return nil, err
+		}
+		if waiters.Version != 2 {
+			return nil, fmt.Errorf("%s: unsupported waiters version %d", waitersPath, waiters.Version)
+		}
+		model.Waiters = waiters.Waiters
+	}
+	for name, op := range model.Operations {
+		if op.Name == "" {
+			op.Name = name
+		}
+	}
+	return model, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	p, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(p, v); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config is the configuration resolved from the shared config and
+// credentials files and the environment, as by the AWS command line tools.
+type Config struct {
+	Profile     string
+	Region      string // default region name, empty if unconfigured
+	Credentials CredentialsProvider
+	EndpointURL string
+	MaxAttempts int // zero if unconfigured
+	S3          S3Config
+
+	UseFIPS      bool
+	UseDualStack bool
+}
+
+// Endpoint resolves the endpoint of service in the configured region,
+// honoring endpoint_url and the FIPS and dual-stack settings.
+func (config *Config) Endpoint(service string) (*Region, error) {
+	resolver := &Resolver{
+		UseFIPS:      config.UseFIPS,
+		UseDualStack: config.UseDualStack,
+	}
+	if config.EndpointURL != "" {
+		resolver.Endpoints = map[string]string{service: config.EndpointURL}
+	}
+	return resolver.Resolve(service, config.Region)
+}
+
+// Configure applies the settings of config which are common to all services
+// to client. A client's Retryer is changed in place, so it must not be shared
+// with other clients.
+func (config *Config) Configure(client *Client) {
+	if config.MaxAttempts > 0 {
+		if client.Retryer == nil {
+			client.Retryer = NewRetryer()
+		}
+		client.Retryer.MaxAttempts = config.MaxAttempts
+	}
+}
+
+// The s3 sub-section of a config profile.
+type S3Config struct {
+	AddressingStyle string // "auto", "path" or "virtual"; see s3.NewClientConfig
+}
+
+// Profile is a named profile merged from the shared config and credentials
+// files. Keys from the credentials file take precedence.
+type Profile struct {
+	Name string
+
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+
+	Region            string
+	RoleArn           string
+	RoleSessionName   string
+	SourceProfile     string
+	CredentialSource  string // "Environment", "Ec2InstanceMetadata" or "EcsContainer"
+	ExternalId        string
+	MfaSerial         string
+	DurationSeconds   int
+	CredentialProcess string
+	EndpointURL       string
+	MaxAttempts       int
+	S3                S3Config
+	UseFIPS           bool
+	UseDualStack      bool
+}
+
+// AssumeRoleFunc returns a provider which assumes profile.RoleArn with
+// credentials from source. See sts.ProfileProvider.
+type AssumeRoleFunc func(source CredentialsProvider, profile *Profile) (CredentialsProvider, error)
+
+// ConfigLoader loads profiles as LoadConfig does, with hooks for the
+// providers which this package cannot implement itself.
+type ConfigLoader struct {
+	// Creates the providers of profiles with a role_arn, which fail to load
+	// if it is nil. Set it to sts.ProfileProvider, or call sts.LoadConfig.
+	AssumeRole AssumeRoleFunc
+}
+
+// LoadConfig resolves the named profile. If profile is empty then
+// $AWS_PROFILE or "default" is used, and credentials in the environment take
+// precedence over the profile's, falling back to the container and EC2
+// instance providers. The region comes from $AWS_REGION,
+// $AWS_DEFAULT_REGION or the profile.
+//
+// Profiles which assume a role with role_arn return an error; load them
+// with sts.LoadConfig instead.
+func LoadConfig(profile string) (*Config, error) {
+	return new(ConfigLoader).Load(profile)
+}
+
+// Load resolves the named profile as LoadConfig does.
+func (loader *ConfigLoader) Load(profile string) (*Config, error) {
+	named := profile != ""
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	explicit := profile != ""
+	if profile == "" {
+		profile = "default"
+	}
+	profiles, err := loadProfiles()
+	if err != nil {
+		return nil, err
+	}
+	p, ok := profiles[profile]
+	if !ok {
+		if explicit {
+			return nil, fmt.Errorf("aws: config: profile %q not found", profile)
+		}
+		p = &Profile{Name: profile}
+	}
+
+	config := &Config{
+		Profile:      profile,
+		Region:       p.Region,
+		EndpointURL:  p.EndpointURL,
+		MaxAttempts:  p.MaxAttempts,
+		S3:           p.S3,
+		UseFIPS:      p.UseFIPS,
+		UseDualStack: p.UseDualStack,
+	}
+	if region := RegionFromEnv(); region != "" {
+		config.Region = region
+	}
+	if fips := os.Getenv("AWS_USE_FIPS_ENDPOINT"); fips != "" {
+		config.UseFIPS = fips == "true"
+	}
+	if dualstack := os.Getenv("AWS_USE_DUALSTACK_ENDPOINT"); dualstack != "" {
+		config.UseDualStack = dualstack == "true"
+	}
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		config.EndpointURL = endpoint
+	}
+	if attempts := os.Getenv("AWS_MAX_ATTEMPTS"); attempts != "" {
+		config.MaxAttempts, err = strconv.Atoi(attempts)
+		if err != nil {
+			return nil, fmt.Errorf("aws: config: AWS_MAX_ATTEMPTS: %v", err)
+		}
+	}
+
+	creds, err := loader.provider(p, profiles, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case named && creds == nil:
+		return nil, fmt.Errorf("aws: config: profile %q has no credentials", profile)
+	case named:
+		config.Credentials = creds
+	default:
+		chain := NewChainProvider(EnvProvider{})
+		if creds != nil {
+			chain.Providers = append(chain.Providers, creds)
+		}
+		chain.Providers = append(chain.Providers, &ContainerProvider{}, &Ec2RoleProvider{})
+		config.Credentials = chain
+	}
+	return config, nil
+}
+
+// Returns nil if the profile does not configure credentials. visited detects
+// source_profile cycles.
+func (loader *ConfigLoader) provider(p *Profile, profiles map[string]*Profile, visited map[string]bool) (CredentialsProvider, error) {
+	if p.RoleArn == "" {
+		switch {
+		case p.AccessKeyId != "":
+			return &Credentials{
+				AccessKeyId:     p.AccessKeyId,
+				SecretAccessKey: p.SecretAccessKey,
+				SessionToken:    p.SessionToken,
+			}, nil
+		case p.CredentialProcess != "":
+			return NewProcessProvider(p.CredentialProcess), nil
+		}
+		return nil, nil
+	}
+
+	if visited == nil {
+		visited = make(map[string]bool)
+	}
+	if visited[p.Name] {
+		return nil, fmt.Errorf("aws: config: profile %q: source_profile cycle", p.Name)
+	}
+	visited[p.Name] = true
+
+	var source CredentialsProvider
+	switch {
+	case p.SourceProfile != "" && p.CredentialSource != "":
+		return nil, fmt.Errorf("aws: config: profile %q: both source_profile and credential_source set", p.Name)
+	case p.SourceProfile == p.Name:
+		// A role profile may hold its own source keys.
+		if p.AccessKeyId == "" {
+			return nil, fmt.Errorf("aws: config: profile %q: source_profile refers to itself but has no keys", p.Name)
+		}
+		source = &Credentials{
+			AccessKeyId:     p.AccessKeyId,
+			SecretAccessKey: p.SecretAccessKey,
+			SessionToken:    p.SessionToken,
+		}
+	case p.SourceProfile != "":
+		sp, ok := profiles[p.SourceProfile]
+		if !ok {
+			return nil, fmt.Errorf("aws: config: profile %q: source_profile %q not found", p.Name, p.SourceProfile)
+		}
+		var err error
+		source, err = loader.provider(sp, profiles, visited)
+		if err != nil {
+			return nil, err
+		}
+		if source == nil {
+			return nil, fmt.Errorf("aws: config: profile %q: source_profile %q has no credentials", p.Name, p.SourceProfile)
+		}
+	case p.CredentialSource == "Environment":
+		source = EnvProvider{}
+	case p.CredentialSource == "Ec2InstanceMetadata":
+		source = &Ec2RoleProvider{}
+	case p.CredentialSource == "EcsContainer":
+		source = &ContainerProvider{}
+	case p.CredentialSource != "":
+		return nil, fmt.Errorf("aws: config: profile %q: unknown credential_source %q", p.Name, p.CredentialSource)
+	default:
+		return nil, fmt.Errorf("aws: config: profile %q: role_arn without source_profile or credential_source", p.Name)
+	}
+	if loader.AssumeRole == nil {
+		return nil, fmt.Errorf("aws: config: profile %q: role_arn requires sts.LoadConfig (package github.com/bmatsuo/go-aws/sts)", p.Name)
+	}
+	return loader.AssumeRole(source, p)
+}
+
+func configFilename() (string, error) {
+	if filename := os.Getenv("AWS_CONFIG_FILE"); filename != "" {
+		return filename, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".aws", "config"), nil
+}
+
+// Reads and merges the profiles of the config and credentials files. Missing
+// files are treated as empty.
+func loadProfiles() (map[string]*Profile, error) {
+	profiles := make(map[string]*Profile)
+	get := func(name string) *Profile {
+		if profiles[name] == nil {
+			profiles[name] = &Profile{Name: name}
+		}
+		return profiles[name]
+	}
+
+	filename, err := configFilename()
+	if err != nil {
+		return nil, fmt.Errorf("aws: config: %v", err)
+	}
+	config, err := readIniFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("aws: config: %v", err)
+	}
+	for section, keys := range config {
+		name := section
+		if section != "default" {
+			if !strings.HasPrefix(section, "profile ") {
+				continue // e.g. [sso-session ...] and [services ...]
+			}
+			name = strings.TrimSpace(strings.TrimPrefix(section, "profile "))
+		}
+		err := get(name).set(keys, filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filename, err = (&SharedCredentialsProvider{}).filename()
+	if err != nil {
+		return nil, fmt.Errorf("aws: config: %v", err)
+	}
+	creds, err := readIniFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("aws: config: %v", err)
+	}
+	for name, keys := range creds {
+		err := get(name).set(keys, filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+func (p *Profile) set(keys map[string]string, filename string) error {
+	var err error
+	atoi := func(key string) int {
+		if keys[key] == "" || err != nil {
+			return 0
+		}
+		var n int
+		n, err = strconv.Atoi(keys[key])
+		if err != nil {
+			err = fmt.Errorf("aws: config: %s: profile %q: %s: %v", filename, p.Name, key, err)
+		}
+		return n
+	}
+	str := func(dst *string, key string) {
+		if v, ok := keys[key]; ok {
+			*dst = v
+		}
+	}
+	num := func(dst *int, key string) {
+		if _, ok := keys[key]; ok {
+			*dst = atoi(key)
+		}
+	}
+	flag := func(dst *bool, key string) {
+		if v, ok := keys[key]; ok {
+			*dst = strings.EqualFold(v, "true")
+		}
+	}
+	str(&p.AccessKeyId, "aws_access_key_id")
+	str(&p.SecretAccessKey, "aws_secret_access_key")
+	str(&p.SessionToken, "aws_session_token")
+	str(&p.Region, "region")
+	str(&p.RoleArn, "role_arn")
+	str(&p.RoleSessionName, "role_session_name")
+	str(&p.SourceProfile, "source_profile")
+	str(&p.CredentialSource, "credential_source")
+	str(&p.ExternalId, "external_id")
+	str(&p.MfaSerial, "mfa_serial")
+	num(&p.DurationSeconds, "duration_seconds")
+	str(&p.CredentialProcess, "credential_process")
+	str(&p.EndpointURL, "endpoint_url")
+	num(&p.MaxAttempts, "max_attempts")
+	str(&p.S3.AddressingStyle, "s3.addressing_style")
+	flag(&p.UseFIPS, "use_fips_endpoint")
+	flag(&p.UseDualStack, "use_dualstack_endpoint")
+	return err
+}
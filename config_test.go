@@ -0,0 +1,146 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+const testConfig = `
+[default]
+region = us-west-2
+
+[profile dev]
+region = eu-west-1
+endpoint_url = http://localhost:9000
+max_attempts = 5
+s3 =
+    addressing_style = path
+
+[profile admin]
+role_arn = arn:aws:iam::123456789012:role/admin
+source_profile = dev
+external_id = xid
+mfa_serial = arn:aws:iam::123456789012:mfa/user
+
+[profile loop]
+role_arn = arn:aws:iam::123456789012:role/loop
+source_profile = loop2
+
+[profile loop2]
+role_arn = arn:aws:iam::123456789012:role/loop2
+source_profile = loop
+
+[profile helper]
+credential_process = echo '{"Version": 1, "AccessKeyId": "AKIDPROC", "SecretAccessKey": "SECRETPROC"}'
+
+[sso-session ignored]
+sso_region = us-east-1
+`
+
+const testConfigCredentials = `
+[dev]
+aws_access_key_id = AKIDDEV
+aws_secret_access_key = SECRETDEV
+`
+
+func setupTestConfig(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", writeTestFile(t, "config", testConfig))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", writeTestFile(t, "credentials", testConfigCredentials))
+	for _, name := range []string{"AWS_PROFILE", "AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ENDPOINT_URL", "AWS_MAX_ATTEMPTS", "AWS_USE_FIPS_ENDPOINT", "AWS_USE_DUALSTACK_ENDPOINT"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	setupTestConfig(t)
+	config, err := LoadConfig("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Region != "eu-west-1" || config.EndpointURL != "http://localhost:9000" || config.MaxAttempts != 5 {
+		t.Errorf("unexpected config: %#v", config)
+	}
+	if config.S3.AddressingStyle != "path" {
+		t.Errorf("unexpected s3 config: %#v", config.S3)
+	}
+	client := NewClient("s3", config.Credentials, nil)
+	config.Configure(client)
+	if client.Retryer.MaxAttempts != 5 {
+		t.Errorf("retryer max attempts %d", client.Retryer.MaxAttempts)
+	}
+	endpoint, err := config.Endpoint("s3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endpoint.Name != "eu-west-1" || endpoint.Protocol != "http" || endpoint.Endpoint != "localhost:9000" {
+		t.Errorf("unexpected endpoint: %#v", endpoint)
+	}
+	creds, err := config.Credentials.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "AKIDDEV" {
+		t.Errorf("unexpected credentials: %#v", creds)
+	}
+
+	config, err = LoadConfig("helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds, err = config.Credentials.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "AKIDPROC" {
+		t.Errorf("unexpected credentials: %#v", creds)
+	}
+
+	t.Setenv("AWS_REGION", "ap-southeast-2")
+	config, err = LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Profile != "default" || config.Region != "ap-southeast-2" {
+		t.Errorf("unexpected config: %#v", config)
+	}
+}
+
+func TestLoadConfigAssumeRole(t *testing.T) {
+	setupTestConfig(t)
+
+	_, err := LoadConfig("admin")
+	if err == nil || !strings.Contains(err.Error(), "sts") {
+		t.Errorf("expected an error naming package sts: %v", err)
+	}
+
+	var profile *Profile
+	var source CredentialsProvider
+	loader := &ConfigLoader{AssumeRole: func(s CredentialsProvider, p *Profile) (CredentialsProvider, error) {
+		source, profile = s, p
+		return &Credentials{AccessKeyId: "ASIAADMIN", SecretAccessKey: "SECRET"}, nil
+	}}
+	_, err = loader.Load("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ExternalId != "xid" || profile.MfaSerial == "" {
+		t.Errorf("unexpected profile: %#v", profile)
+	}
+	creds, err := source.Retrieve()
+	if err != nil || creds.AccessKeyId != "AKIDDEV" {
+		t.Errorf("unexpected source credentials: %#v %v", creds, err)
+	}
+
+	_, err = loader.Load("loop")
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error: %v", err)
+	}
+	_, err = loader.Load("missing")
+	if err == nil {
+		t.Errorf("expected an error for a missing profile")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// The host serving ECS task credentials for relative URIs.
+const ContainerCredentialsHost = "http://169.254.170.2"
+
+// ContainerProvider retrieves credentials from the ECS task or EKS pod
+// identity credentials endpoint. Credentials are cached and refreshed
+// ExpiryWindow before they expire. Safe for concurrent use.
+type ContainerProvider struct {
+	// Defaults to ContainerCredentialsHost joined with
+	// $AWS_CONTAINER_CREDENTIALS_RELATIVE_URI, or
+	// $AWS_CONTAINER_CREDENTIALS_FULL_URI.
+	Endpoint string
+
+	// The Authorization header value. Defaults to the contents of
+	// $AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE, which is read on every
+	// refresh, or $AWS_CONTAINER_AUTHORIZATION_TOKEN.
+	AuthorizationToken     string
+	AuthorizationTokenFile string
+
+	// Defaults to a client with a five second timeout.
+	Client *http.Client
+
+	ExpiryWindow time.Duration // defaults to DefaultExpiryWindow
+
+	cache CredentialsCache
+}
+
+func (p *ContainerProvider) Retrieve() (*Credentials, error) {
+	window := p.ExpiryWindow
+	if window == 0 {
+		window = DefaultExpiryWindow
+	}
+	return p.cache.Retrieve(window, p.fetch)
+}
+
+func (p *ContainerProvider) endpoint() (string, error) {
+	if p.Endpoint != "" {
+		return p.Endpoint, nil
+	}
+	if rel := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); rel != "" {
+		return ContainerCredentialsHost + rel, nil
+	}
+	full := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if full == "" {
+		return "", errors.New("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI and AWS_CONTAINER_CREDENTIALS_FULL_URI not set in environment")
+	}
+	uri, err := url.Parse(full)
+	if err != nil {
+		return "", err
+	}
+	if uri.Scheme != "https" && !containerHostAllowed(uri.Hostname()) {
+		return "", fmt.Errorf("full uri host %q is not a loopback or container credentials address", uri.Hostname())
+	}
+	return full, nil
+}
+
+// Plain http full URIs may only point at the local host or the ECS and EKS
+// credentials addresses.
+func containerHostAllowed(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	return ip.IsLoopback() ||
+		ip.Equal(net.ParseIP("169.254.170.2")) ||
+		ip.Equal(net.ParseIP("169.254.170.23")) ||
+		ip.Equal(net.ParseIP("fd00:ec2::23"))
+}
+
+func (p *ContainerProvider) authorization() (string, error) {
+	if p.AuthorizationToken != "" {
+		return p.AuthorizationToken, nil
+	}
+	filename := p.AuthorizationTokenFile
+	if filename == "" {
+		filename = os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE")
+	}
+	if filename != "" {
+		token, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+	return os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN"), nil
+}
+
+func (p *ContainerProvider) fetch() (*Credentials, error) {
+	endpoint, err := p.endpoint()
+	if err != nil {
+		return nil, fmt.Errorf("aws: container credentials: %v", err)
+	}
+	auth, err := p.authorization()
+	if err != nil {
+		return nil, fmt.Errorf("aws: container credentials: %v", err)
+	}
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("aws: container credentials: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("aws: container credentials: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("aws: container credentials: %v", err)
+	}
+	var doc credentialsDocument
+	if resp.StatusCode != http.StatusOK {
+		if json.Unmarshal(body, &doc) == nil && doc.Code != "" {
+			return nil, fmt.Errorf("aws: container credentials: %s: %s: %s", resp.Status, doc.Code, doc.Message)
+		}
+		return nil, fmt.Errorf("aws: container credentials: %s", resp.Status)
+	}
+	err = json.Unmarshal(body, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("aws: container credentials: %v", err)
+	}
+	creds, err := doc.credentials()
+	if err != nil {
+		return nil, fmt.Errorf("aws: container credentials: %v", err)
+	}
+	return creds, nil
+}
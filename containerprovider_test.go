@@ -0,0 +1,57 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestContainerProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/credentials/task" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"code":"AccessDenied","message":"bad token"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"AccessKeyId":"AKID","SecretAccessKey":"SECRET","Token":"SESSION","Expiration":%q}`,
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	tokenfile := writeTestFile(t, "token", "Bearer secret\n")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL+"/v2/credentials/task")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", tokenfile)
+
+	creds, err := (&ContainerProvider{}).Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "AKID" || creds.SessionToken != "SESSION" || creds.Expiration.IsZero() {
+		t.Errorf("unexpected credentials: %#v", creds)
+	}
+
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", "")
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN", "Bearer wrong")
+	_, err = (&ContainerProvider{}).Retrieve()
+	if err == nil {
+		t.Errorf("expected an error for a bad authorization token")
+	}
+
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "http://example.com/creds")
+	_, err = (&ContainerProvider{}).Retrieve()
+	if err == nil {
+		t.Errorf("expected an error for a non-local http full uri")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// The address of the EC2 instance metadata service.
+	DefaultMetadataEndpoint = "http://169.254.169.254"
+
+	// The lifetime requested for IMDSv2 session tokens.
+	DefaultMetadataTokenTTL = 6 * time.Hour
+)
+
+// Ec2RoleProvider retrieves the credentials of an EC2 instance's IAM role
+// from the instance metadata service. An IMDSv2 session token is requested
+// first; if that fails and AllowIMDSv1 is set the credentials are requested
+// without one. Credentials are cached and refreshed ExpiryWindow before they
+// expire. Safe for concurrent use.
+type Ec2RoleProvider struct {
+	// Defaults to $AWS_EC2_METADATA_SERVICE_ENDPOINT, or
+	// DefaultMetadataEndpoint.
+	Endpoint string
+
+	// Defaults to a client with a one second timeout.
+	Client *http.Client
+
+	AllowIMDSv1  bool
+	TokenTTL     time.Duration // defaults to DefaultMetadataTokenTTL
+	ExpiryWindow time.Duration // defaults to DefaultExpiryWindow
+
+	cache CredentialsCache
+}
+
+// The JSON credentials document served by the metadata and container
+// credentials endpoints.
+type credentialsDocument struct {
+	Code            string
+	Message         string
+	AccessKeyId     string
+	SecretAccessKey string
+	Token           string
+	Expiration      time.Time
+}
+
+func (doc *credentialsDocument) credentials() (*Credentials, error) {
+	if doc.Code != "" && doc.Code != "Success" {
+		return nil, fmt.Errorf("%s: %s", doc.Code, doc.Message)
+	}
+	if doc.AccessKeyId == "" || doc.SecretAccessKey == "" {
+		return nil, fmt.Errorf("credentials document has no keys")
+	}
+	return &Credentials{
+		AccessKeyId:     doc.AccessKeyId,
+		SecretAccessKey: doc.SecretAccessKey,
+		SessionToken:    doc.Token,
+		Expiration:      doc.Expiration,
+	}, nil
+}
+
+func (p *Ec2RoleProvider) Retrieve() (*Credentials, error) {
+	window := p.ExpiryWindow
+	if window == 0 {
+		window = DefaultExpiryWindow
+	}
+	return p.cache.Retrieve(window, p.fetch)
+}
+
+func (p *Ec2RoleProvider) endpoint() string {
+	if p.Endpoint != "" {
+		return strings.TrimRight(p.Endpoint, "/")
+	}
+	if endpoint := os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"); endpoint != "" {
+		return strings.TrimRight(endpoint, "/")
+	}
+	return DefaultMetadataEndpoint
+}
+
+func (p *Ec2RoleProvider) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return &http.Client{Timeout: time.Second}
+}
+
+func (p *Ec2RoleProvider) fetch() (*Credentials, error) {
+	token, err := p.token()
+	if err != nil && !p.AllowIMDSv1 {
+		return nil, fmt.Errorf("aws: ec2 metadata: %v", err)
+	}
+	path := "/latest/meta-data/iam/security-credentials/"
+	roles, err := p.get(token, path)
+	if err != nil {
+		return nil, fmt.Errorf("aws: ec2 metadata: %v", err)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(string(roles)))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) == "" {
+		return nil, fmt.Errorf("aws: ec2 metadata: no instance profile role")
+	}
+	role := strings.TrimSpace(scanner.Text())
+	body, err := p.get(token, path+role)
+	if err != nil {
+		return nil, fmt.Errorf("aws: ec2 metadata: %v", err)
+	}
+	var doc credentialsDocument
+	err = json.Unmarshal(body, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("aws: ec2 metadata: role %s: %v", role, err)
+	}
+	creds, err := doc.credentials()
+	if err != nil {
+		return nil, fmt.Errorf("aws: ec2 metadata: role %s: %v", role, err)
+	}
+	return creds, nil
+}
+
+// Requests an IMDSv2 session token.
+func (p *Ec2RoleProvider) token() (string, error) {
+	ttl := p.TokenTTL
+	if ttl == 0 {
+		ttl = DefaultMetadataTokenTTL
+	}
+	req, err := http.NewRequest("PUT", p.endpoint()+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", strconv.Itoa(int(ttl/time.Second)))
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("session token: %s", resp.Status)
+	}
+	return string(body), nil
+}
+
+func (p *Ec2RoleProvider) get(token, path string) ([]byte, error) {
+	req, err := http.NewRequest("GET", p.endpoint()+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+	}
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	return body, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Serves role credentials which expire after lifetime. If tokens is false
+// the IMDSv2 token endpoint is disabled.
+func testMetadataServer(t *testing.T, tokens bool, lifetime time.Duration, fetches *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		if !tokens || r.Method != "PUT" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds") == "" {
+			http.Error(w, "missing ttl", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, "TOKEN")
+	})
+	authorized := func(w http.ResponseWriter, r *http.Request) bool {
+		if tokens && r.Header.Get("X-aws-ec2-metadata-token") != "TOKEN" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/", func(w http.ResponseWriter, r *http.Request) {
+		if authorized(w, r) {
+			fmt.Fprint(w, "worker-role\n")
+		}
+	})
+	mux.HandleFunc("/latest/meta-data/iam/security-credentials/worker-role", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(w, r) {
+			return
+		}
+		n := atomic.AddInt32(fetches, 1)
+		expires := time.Now().Add(lifetime).UTC().Format(time.RFC3339)
+		fmt.Fprintf(w, `{
+  "Code" : "Success",
+  "Type" : "AWS-HMAC",
+  "AccessKeyId" : "AKID%d",
+  "SecretAccessKey" : "SECRET",
+  "Token" : "SESSION",
+  "Expiration" : %q
+}`, n, expires)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEc2RoleProvider(t *testing.T) {
+	var fetches int32
+	server := testMetadataServer(t, true, time.Hour, &fetches)
+	p := &Ec2RoleProvider{Endpoint: server.URL}
+	for i := 0; i < 3; i++ {
+		creds, err := p.Retrieve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds.AccessKeyId != "AKID1" || creds.SessionToken != "SESSION" {
+			t.Errorf("unexpected credentials: %#v", creds)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("credentials fetched %d times", fetches)
+	}
+}
+
+func TestEc2RoleProviderRefresh(t *testing.T) {
+	var fetches int32
+	server := testMetadataServer(t, true, 2*time.Minute, &fetches)
+	p := &Ec2RoleProvider{Endpoint: server.URL}
+	for i := 0; i < 2; i++ {
+		_, err := p.Retrieve()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("credentials within the expiry window were not refreshed")
+	}
+}
+
+func TestEc2RoleProviderIMDSv1(t *testing.T) {
+	var fetches int32
+	server := testMetadataServer(t, false, time.Hour, &fetches)
+	_, err := (&Ec2RoleProvider{Endpoint: server.URL}).Retrieve()
+	if err == nil {
+		t.Errorf("expected an error without IMDSv1 fallback")
+	}
+	creds, err := (&Ec2RoleProvider{Endpoint: server.URL, AllowIMDSv1: true}).Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "AKID1" {
+		t.Errorf("unexpected credentials: %#v", creds)
+	}
+}
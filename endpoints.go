@@ -0,0 +1,182 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Region names.
+const (
+	USEast1      = "us-east-1"
+	USEast2      = "us-east-2"
+	USWest1      = "us-west-1"
+	USWest2      = "us-west-2"
+	CACentral1   = "ca-central-1"
+	SAEast1      = "sa-east-1"
+	EUWest1      = "eu-west-1"
+	EUWest2      = "eu-west-2"
+	EUWest3      = "eu-west-3"
+	EUCentral1   = "eu-central-1"
+	EUNorth1     = "eu-north-1"
+	EUSouth1     = "eu-south-1"
+	APEast1      = "ap-east-1"
+	APSouth1     = "ap-south-1"
+	APSoutheast1 = "ap-southeast-1"
+	APSoutheast2 = "ap-southeast-2"
+	APNortheast1 = "ap-northeast-1"
+	APNortheast2 = "ap-northeast-2"
+	APNortheast3 = "ap-northeast-3"
+	MECentral1   = "me-central-1"
+	MESouth1     = "me-south-1"
+	AFSouth1     = "af-south-1"
+	CNNorth1     = "cn-north-1"
+	CNNorthwest1 = "cn-northwest-1"
+	USGovWest1   = "us-gov-west-1"
+	USGovEast1   = "us-gov-east-1"
+)
+
+// A Partition is a group of regions sharing a DNS suffix.
+type Partition struct {
+	ID                 string
+	DNSSuffix          string
+	DualStackDNSSuffix string
+	RegionRegex        *regexp.Regexp
+
+	// Hostnames and signing regions of services without regional endpoints.
+	Global map[string]Endpoint
+}
+
+// The hostname and signing region of a global service.
+type Endpoint struct {
+	Hostname string
+	Region   string
+}
+
+var (
+	PartitionAWS = &Partition{
+		ID:                 "aws",
+		DNSSuffix:          "amazonaws.com",
+		DualStackDNSSuffix: "api.aws",
+		RegionRegex:        regexp.MustCompile(`^(us|eu|ap|sa|ca|me|af|il|mx)-\w+-\d+$`),
+		Global: map[string]Endpoint{
+			"iam":        {"iam.amazonaws.com", USEast1},
+			"route53":    {"route53.amazonaws.com", USEast1},
+			"cloudfront": {"cloudfront.amazonaws.com", USEast1},
+		},
+	}
+	PartitionAWSCN = &Partition{
+		ID:                 "aws-cn",
+		DNSSuffix:          "amazonaws.com.cn",
+		DualStackDNSSuffix: "api.amazonwebservices.com.cn",
+		RegionRegex:        regexp.MustCompile(`^cn-\w+-\d+$`),
+		Global: map[string]Endpoint{
+			"iam":     {"iam.cn-north-1.amazonaws.com.cn", CNNorth1},
+			"route53": {"route53.amazonaws.com.cn", CNNorthwest1},
+		},
+	}
+	PartitionAWSUSGov = &Partition{
+		ID:                 "aws-us-gov",
+		DNSSuffix:          "amazonaws.com",
+		DualStackDNSSuffix: "api.aws",
+		RegionRegex:        regexp.MustCompile(`^us-gov-\w+-\d+$`),
+		Global: map[string]Endpoint{
+			"iam":     {"iam.us-gov.amazonaws.com", USGovWest1},
+			"route53": {"route53.us-gov.amazonaws.com", USGovWest1},
+		},
+	}
+
+	// Checked in order; the first partition matching a region is used.
+	Partitions = []*Partition{PartitionAWSUSGov, PartitionAWSCN, PartitionAWS}
+)
+
+// PartitionOf returns the partition containing region. Unrecognized region
+// names are assumed to be in the aws partition.
+func PartitionOf(region string) *Partition {
+	for _, p := range Partitions {
+		if p.RegionRegex.MatchString(region) {
+			return p
+		}
+	}
+	return PartitionAWS
+}
+
+// RegionFromEnv returns $AWS_REGION or $AWS_DEFAULT_REGION.
+func RegionFromEnv() string {
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+var validRegion = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Resolver maps services and region names to endpoints.
+type Resolver struct {
+	// Custom endpoint URLs by service, which take precedence over all
+	// other rules (e.g. {"s3": "http://localhost:9000"}). Requests are made
+	// to the root of the endpoint, so the URLs may not have a path.
+	Endpoints map[string]string
+
+	UseFIPS      bool
+	UseDualStack bool
+}
+
+// The resolver used by ResolveEndpoint.
+var DefaultResolver = &Resolver{}
+
+// ResolveEndpoint calls DefaultResolver.Resolve.
+func ResolveEndpoint(service, region string) (*Region, error) {
+	return DefaultResolver.Resolve(service, region)
+}
+
+// Resolve returns the endpoint of service in region. The service is the
+// endpoint prefix (e.g. "email" for SES) and the returned Region's Name is
+// the region used for signing.
+func (resolver *Resolver) Resolve(service, region string) (*Region, error) {
+	if region == "" {
+		return nil, fmt.Errorf("aws: endpoint for %s: no region", service)
+	}
+	if !validRegion.MatchString(region) {
+		return nil, fmt.Errorf("aws: endpoint for %s: invalid region %q", service, region)
+	}
+	if custom, ok := resolver.Endpoints[service]; ok {
+		uri, err := url.Parse(custom)
+		if err != nil {
+			return nil, fmt.Errorf("aws: endpoint for %s: %v", service, err)
+		}
+		if uri.Scheme == "" || uri.Host == "" {
+			return nil, fmt.Errorf("aws: endpoint for %s: %q is not an absolute url", service, custom)
+		}
+		if strings.Trim(uri.Path, "/") != "" || uri.RawQuery != "" || uri.Fragment != "" {
+			return nil, fmt.Errorf("aws: endpoint for %s: %q has a path, which is not supported", service, custom)
+		}
+		return &Region{Name: region, Protocol: uri.Scheme, Endpoint: uri.Host}, nil
+	}
+
+	p := PartitionOf(region)
+	if global, ok := p.Global[service]; ok && !resolver.UseFIPS && !resolver.UseDualStack {
+		return &Region{Name: global.Region, Protocol: "https", Endpoint: global.Hostname}, nil
+	}
+	prefix := service
+	if resolver.UseFIPS {
+		prefix += "-fips"
+	}
+	var host string
+	switch {
+	case resolver.UseDualStack && service == "s3":
+		// S3 predates the dual-stack DNS suffixes.
+		host = prefix + ".dualstack." + region + "." + p.DNSSuffix
+	case resolver.UseDualStack:
+		host = prefix + "." + region + "." + p.DualStackDNSSuffix
+	default:
+		host = prefix + "." + region + "." + p.DNSSuffix
+	}
+	return &Region{Name: region, Protocol: "https", Endpoint: strings.ToLower(host)}, nil
+}
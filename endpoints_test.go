@@ -0,0 +1,63 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolver(t *testing.T) {
+	for _, test := range []struct {
+		resolver *Resolver
+		service  string
+		region   string
+		name     string
+		host     string
+	}{
+		{&Resolver{}, "s3", USWest2, USWest2, "s3.us-west-2.amazonaws.com"},
+		{&Resolver{}, "email", USEast1, USEast1, "email.us-east-1.amazonaws.com"},
+		{&Resolver{}, "s3", CNNorth1, CNNorth1, "s3.cn-north-1.amazonaws.com.cn"},
+		{&Resolver{}, "sts", USGovWest1, USGovWest1, "sts.us-gov-west-1.amazonaws.com"},
+		{&Resolver{}, "iam", EUWest1, USEast1, "iam.amazonaws.com"},
+		{&Resolver{}, "iam", USGovEast1, USGovWest1, "iam.us-gov.amazonaws.com"},
+		{&Resolver{UseFIPS: true}, "sts", USEast1, USEast1, "sts-fips.us-east-1.amazonaws.com"},
+		{&Resolver{UseDualStack: true}, "s3", EUCentral1, EUCentral1, "s3.dualstack.eu-central-1.amazonaws.com"},
+		{&Resolver{UseDualStack: true}, "email", EUWest1, EUWest1, "email.eu-west-1.api.aws"},
+		{&Resolver{UseFIPS: true, UseDualStack: true}, "kms", CNNorthwest1, CNNorthwest1, "kms-fips.cn-northwest-1.api.amazonwebservices.com.cn"},
+	} {
+		region, err := test.resolver.Resolve(test.service, test.region)
+		if err != nil {
+			t.Errorf("%s %s: %v", test.service, test.region, err)
+			continue
+		}
+		if region.Name != test.name || region.Endpoint != test.host || region.Protocol != "https" {
+			t.Errorf("%s %s: %#v", test.service, test.region, region)
+		}
+	}
+}
+
+func TestResolverErrors(t *testing.T) {
+	for _, region := range []string{"", "us-east-1.evil.com", "US_EAST_1"} {
+		_, err := ResolveEndpoint("s3", region)
+		if err == nil {
+			t.Errorf("region %q: expected an error", region)
+		}
+	}
+	_, err := (&Resolver{Endpoints: map[string]string{"s3": "localhost:9000"}}).Resolve("s3", USEast1)
+	if err == nil {
+		t.Errorf("expected an error for a relative endpoint url")
+	}
+	for _, endpoint := range []string{"http://localhost:9000/", "http://localhost:9000"} {
+		region, err := (&Resolver{Endpoints: map[string]string{"s3": endpoint}}).Resolve("s3", USEast1)
+		if err != nil || region.Url("", "/key", nil).String() != "http://localhost:9000/key" {
+			t.Errorf("endpoint %q: %v %v", endpoint, region, err)
+		}
+	}
+	_, err = (&Resolver{Endpoints: map[string]string{"s3": "http://localhost:9000/proxy"}}).Resolve("s3", USEast1)
+	if err == nil || !strings.Contains(err.Error(), "path") {
+		t.Errorf("endpoint url with a path: %v", err)
+	}
+}
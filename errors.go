@@ -0,0 +1,98 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// APIError is implemented by the errors service packages return for
+// unsuccessful responses. Use errors.As to inspect an error returned by a
+// client:
+//
+//	var apierr aws.APIError
+//	if errors.As(err, &apierr) && apierr.HTTPStatusCode() == 404 {
+//		...
+//	}
+type APIError interface {
+	error
+	ErrorCoder
+	ErrorMessage() string
+	RequestID() string
+	HostID() string // S3's extended request id; empty for most services
+	HTTPStatusCode() int
+	Retryable() bool
+}
+
+// Code is an AWS error code. API errors match their code with errors.Is:
+//
+//	if errors.Is(err, s3.NoSuchKey) {
+//		...
+//	}
+type Code string
+
+func (code Code) Error() string {
+	return string(code)
+}
+
+// IsCode reports whether target is a Code equal to code. API errors call it
+// from their Is method.
+func IsCode(code string, target error) bool {
+	c, ok := target.(Code)
+	return ok && string(c) == code
+}
+
+// Codes used for responses whose body does not have an error code, such as
+// responses to HEAD requests or errors from proxies.
+var statusCodes = map[int]string{
+	http.StatusNotModified:        "NotModified",
+	http.StatusBadRequest:         "BadRequest",
+	http.StatusForbidden:          "Forbidden",
+	http.StatusNotFound:           "NotFound",
+	http.StatusPreconditionFailed: "PreconditionFailed",
+}
+
+// StatusErrorCode returns the error code for a response with the given
+// status when the body does not have one.
+func StatusErrorCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if text := http.StatusText(status); text != "" {
+		return strings.Replace(text, " ", "", -1)
+	}
+	return "HTTP" + strconv.Itoa(status)
+}
+
+// RetryableError returns true if an error with the given code and HTTP
+// status may not recur when the request is sent again.
+func RetryableError(code string, status int) bool {
+	return retryableCodes[code] || retryableStatus(status)
+}
+
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// IsXML returns true if contentType is an XML media type, with or without
+// parameters (e.g. "application/xml; charset=utf-8").
+func IsXML(contentType string) bool {
+	mediatype, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediatype == "application/xml" || mediatype == "text/xml"
+}
@@ -0,0 +1,44 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStatusErrorCode(t *testing.T) {
+	for status, code := range map[int]string{
+		304: "NotModified",
+		404: "NotFound",
+		502: "BadGateway",
+		599: "HTTP599",
+	} {
+		if got := StatusErrorCode(status); got != code {
+			t.Errorf("status %d: code %q", status, got)
+		}
+	}
+}
+
+func TestIsXML(t *testing.T) {
+	for contentType, xml := range map[string]bool{
+		"application/xml":                true,
+		"application/xml; charset=utf-8": true,
+		"text/xml":                       true,
+		"text/html":                      false,
+		"":                               false,
+	} {
+		if IsXML(contentType) != xml {
+			t.Errorf("%q: %v", contentType, !xml)
+		}
+	}
+}
+
+func TestCode(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &net503{errors.New("unavailable")})
+	if !Retryable(nil, err) {
+		t.Errorf("not retryable")
+	}
+	if !IsCode("NoSuchKey", Code("NoSuchKey")) || IsCode("NoSuchKey", errors.New("NoSuchKey")) {
+		t.Errorf("IsCode")
+	}
+}
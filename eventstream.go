@@ -0,0 +1,567 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The content type of event stream request and response bodies, which are
+// sequences of binary messages. S3 SelectObjectContent, Kinesis
+// SubscribeToShard and the streaming Transcribe APIs use event streams.
+const EventStreamContentType = "application/vnd.amazon.eventstream"
+
+// The payload hash signed for requests whose event stream body is signed
+// with an EventStreamSigner.
+const StreamingEventsPayload = "STREAMING-AWS4-HMAC-SHA256-EVENTS"
+
+// Limits of the event stream message format.
+const (
+	MaxEventMessageLength = 16 << 20
+	MaxEventHeadersLength = 128 << 10
+)
+
+const (
+	eventPreludeLength = 12 // total length, headers length, prelude crc
+	eventMinLength     = eventPreludeLength + 4
+)
+
+// Event stream header value types.
+const (
+	eventHeaderTrue = iota
+	eventHeaderFalse
+	eventHeaderByte
+	eventHeaderShort
+	eventHeaderInt
+	eventHeaderLong
+	eventHeaderBytes
+	eventHeaderString
+	eventHeaderTimestamp
+	eventHeaderUUID
+)
+
+// EventHeader is a header of an event stream message. Its Value is a bool,
+// int8, int16, int32, int64, []byte, string, time.Time (with millisecond
+// precision) or a [16]byte UUID.
+type EventHeader struct {
+	Name  string
+	Value interface{}
+}
+
+// EventMessage is a message of an event stream.
+type EventMessage struct {
+	Headers []EventHeader
+	Payload []byte
+}
+
+// Header returns the value of the named header, or nil if msg does not have
+// it.
+func (msg *EventMessage) Header(name string) interface{} {
+	for _, h := range msg.Headers {
+		if h.Name == name {
+			return h.Value
+		}
+	}
+	return nil
+}
+
+// Returns the value of a string header, or "" if msg does not have it.
+func (msg *EventMessage) headerString(name string) string {
+	s, _ := msg.Header(name).(string)
+	return s
+}
+
+// MarshalBinary encodes msg, with its prelude and checksums.
+func (msg *EventMessage) MarshalBinary() ([]byte, error) {
+	headers, err := encodeEventHeaders(msg.Headers)
+	if err != nil {
+		return nil, err
+	}
+	if len(headers) > MaxEventHeadersLength {
+		return nil, fmt.Errorf("aws: event message headers are %d bytes, over the limit of %d", len(headers), MaxEventHeadersLength)
+	}
+	total := eventMinLength + len(headers) + len(msg.Payload)
+	if total > MaxEventMessageLength {
+		return nil, fmt.Errorf("aws: event message is %d bytes, over the limit of %d", total, MaxEventMessageLength)
+	}
+	p := make([]byte, eventPreludeLength, total)
+	binary.BigEndian.PutUint32(p[0:], uint32(total))
+	binary.BigEndian.PutUint32(p[4:], uint32(len(headers)))
+	binary.BigEndian.PutUint32(p[8:], crc32.ChecksumIEEE(p[:8]))
+	p = append(p, headers...)
+	p = append(p, msg.Payload...)
+	return binary.BigEndian.AppendUint32(p, crc32.ChecksumIEEE(p)), nil
+}
+
+func encodeEventHeaders(headers []EventHeader) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, h := range headers {
+		if len(h.Name) == 0 || len(h.Name) > 255 {
+			return nil, fmt.Errorf("aws: invalid event header name %q", h.Name)
+		}
+		buf.WriteByte(byte(len(h.Name)))
+		buf.WriteString(h.Name)
+		switch v := h.Value.(type) {
+		case bool:
+			if v {
+				buf.WriteByte(eventHeaderTrue)
+			} else {
+				buf.WriteByte(eventHeaderFalse)
+			}
+		case int8:
+			buf.WriteByte(eventHeaderByte)
+			buf.WriteByte(byte(v))
+		case int16:
+			buf.WriteByte(eventHeaderShort)
+			binary.Write(&buf, binary.BigEndian, v)
+		case int32:
+			buf.WriteByte(eventHeaderInt)
+			binary.Write(&buf, binary.BigEndian, v)
+		case int64:
+			buf.WriteByte(eventHeaderLong)
+			binary.Write(&buf, binary.BigEndian, v)
+		case []byte:
+			if len(v) > 0xffff {
+				return nil, fmt.Errorf("aws: event header %s is too long", h.Name)
+			}
+			buf.WriteByte(eventHeaderBytes)
+			binary.Write(&buf, binary.BigEndian, uint16(len(v)))
+			buf.Write(v)
+		case string:
+			if len(v) > 0xffff {
+				return nil, fmt.Errorf("aws: event header %s is too long", h.Name)
+			}
+			buf.WriteByte(eventHeaderString)
+			binary.Write(&buf, binary.BigEndian, uint16(len(v)))
+			buf.WriteString(v)
+		case time.Time:
+			buf.WriteByte(eventHeaderTimestamp)
+			binary.Write(&buf, binary.BigEndian, v.UnixNano()/int64(time.Millisecond))
+		case [16]byte:
+			buf.WriteByte(eventHeaderUUID)
+			buf.Write(v[:])
+		default:
+			return nil, fmt.Errorf("aws: event header %s has unsupported type %T", h.Name, h.Value)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadEventMessage reads a message from r, verifying its checksums. It
+// returns io.EOF if r ends before the message begins, and
+// io.ErrUnexpectedEOF if r ends within it.
+func ReadEventMessage(r io.Reader) (*EventMessage, error) {
+	prelude := make([]byte, eventPreludeLength)
+	if _, err := io.ReadFull(r, prelude); err != nil {
+		return nil, err
+	}
+	total := binary.BigEndian.Uint32(prelude[0:])
+	headersLen := binary.BigEndian.Uint32(prelude[4:])
+	if crc := crc32.ChecksumIEEE(prelude[:8]); crc != binary.BigEndian.Uint32(prelude[8:]) {
+		return nil, fmt.Errorf("aws: event message prelude crc32 %d does not match %d", crc, binary.BigEndian.Uint32(prelude[8:]))
+	}
+	if total < eventMinLength || total > MaxEventMessageLength {
+		return nil, fmt.Errorf("aws: invalid event message length %d", total)
+	}
+	if headersLen > MaxEventHeadersLength || headersLen > total-eventMinLength {
+		return nil, fmt.Errorf("aws: invalid event message headers length %d", headersLen)
+	}
+	p := make([]byte, total)
+	copy(p, prelude)
+	if _, err := io.ReadFull(r, p[eventPreludeLength:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	end := total - 4
+	if crc := crc32.ChecksumIEEE(p[:end]); crc != binary.BigEndian.Uint32(p[end:]) {
+		return nil, fmt.Errorf("aws: event message crc32 %d does not match %d", crc, binary.BigEndian.Uint32(p[end:]))
+	}
+	headers, err := decodeEventHeaders(p[eventPreludeLength : eventPreludeLength+headersLen])
+	if err != nil {
+		return nil, err
+	}
+	return &EventMessage{Headers: headers, Payload: p[eventPreludeLength+headersLen : end]}, nil
+}
+
+var errEventHeaders = errors.New("aws: malformed event message headers")
+
+func decodeEventHeaders(p []byte) ([]EventHeader, error) {
+	var headers []EventHeader
+	next := func(n int) []byte {
+		if n > len(p) {
+			return nil
+		}
+		b := p[:n]
+		p = p[n:]
+		return b
+	}
+	for len(p) > 0 {
+		n := int(p[0])
+		p = p[1:]
+		name := next(n)
+		typ := next(1)
+		if n == 0 || name == nil || typ == nil {
+			return nil, errEventHeaders
+		}
+		var value interface{}
+		var b []byte
+		switch typ[0] {
+		case eventHeaderTrue:
+			value = true
+		case eventHeaderFalse:
+			value = false
+		case eventHeaderByte:
+			if b = next(1); b != nil {
+				value = int8(b[0])
+			}
+		case eventHeaderShort:
+			if b = next(2); b != nil {
+				value = int16(binary.BigEndian.Uint16(b))
+			}
+		case eventHeaderInt:
+			if b = next(4); b != nil {
+				value = int32(binary.BigEndian.Uint32(b))
+			}
+		case eventHeaderLong:
+			if b = next(8); b != nil {
+				value = int64(binary.BigEndian.Uint64(b))
+			}
+		case eventHeaderBytes, eventHeaderString:
+			if b = next(2); b != nil {
+				b = next(int(binary.BigEndian.Uint16(b)))
+			}
+			if b != nil && typ[0] == eventHeaderString {
+				value = string(b)
+			} else if b != nil {
+				value = append([]byte{}, b...)
+			}
+		case eventHeaderTimestamp:
+			if b = next(8); b != nil {
+				ms := int64(binary.BigEndian.Uint64(b))
+				value = time.Unix(ms/1000, ms%1000*int64(time.Millisecond)).UTC()
+			}
+		case eventHeaderUUID:
+			if b = next(16); b != nil {
+				var uuid [16]byte
+				copy(uuid[:], b)
+				value = uuid
+			}
+		default:
+			return nil, fmt.Errorf("aws: unknown type %d of event header %s", typ[0], name)
+		}
+		if value == nil {
+			return nil, errEventHeaders
+		}
+		headers = append(headers, EventHeader{string(name), value})
+	}
+	return headers, nil
+}
+
+// Event is an event read from an event stream.
+type Event struct {
+	*EventMessage
+	Type        string // the :event-type header
+	ContentType string // the :content-type header
+
+	// The decoded payload, if the event's type is in the reader's Events.
+	Value interface{}
+}
+
+// Decode decodes the event's payload into v, as JSON or XML depending on
+// its content type. A *[]byte is set to the raw payload.
+func (event *Event) Decode(v interface{}) error {
+	if p, ok := v.(*[]byte); ok {
+		*p = event.Payload
+		return nil
+	}
+	if IsXML(event.ContentType) {
+		return xml.Unmarshal(event.Payload, v)
+	}
+	return json.Unmarshal(event.Payload, v)
+}
+
+// EventStreamError is an exception or error message received in an event
+// stream. Exceptions are modeled errors of the API; other errors are
+// failures of the stream. EventStreamError implements APIError.
+type EventStreamError struct {
+	Exception bool
+	Code      string // the :exception-type or :error-code header
+	Message   string
+	Payload   []byte
+}
+
+func (err *EventStreamError) Error() string {
+	if err.Message == "" {
+		return "aws: event stream: " + err.Code
+	}
+	return "aws: event stream: " + err.Code + ": " + err.Message
+}
+
+func (err *EventStreamError) Is(target error) bool { return IsCode(err.Code, target) }
+func (err *EventStreamError) ErrorCode() string    { return err.Code }
+func (err *EventStreamError) ErrorMessage() string { return err.Message }
+func (err *EventStreamError) RequestID() string    { return "" }
+func (err *EventStreamError) HostID() string       { return "" }
+func (err *EventStreamError) HTTPStatusCode() int  { return http.StatusOK }
+func (err *EventStreamError) Retryable() bool      { return retryableCodes[err.Code] }
+
+// Returns the error of an exception or error message, or nil for events.
+func eventStreamError(msg *EventMessage) error {
+	switch typ := msg.headerString(":message-type"); typ {
+	case "", "event":
+		return nil
+	case "exception":
+		err := &EventStreamError{
+			Exception: true,
+			Code:      msg.headerString(":exception-type"),
+			Payload:   msg.Payload,
+		}
+		var body struct {
+			Message  string `json:"message" xml:"Message"`
+			Message2 string `json:"Message" xml:"message"`
+		}
+		if IsXML(msg.headerString(":content-type")) {
+			xml.Unmarshal(msg.Payload, &body)
+		} else {
+			json.Unmarshal(msg.Payload, &body)
+		}
+		err.Message = body.Message
+		if err.Message == "" {
+			err.Message = body.Message2
+		}
+		return err
+	case "error":
+		return &EventStreamError{
+			Code:    msg.headerString(":error-code"),
+			Message: msg.headerString(":error-message"),
+			Payload: msg.Payload,
+		}
+	default:
+		return fmt.Errorf("aws: unknown event stream message type %q", typ)
+	}
+}
+
+// EventStreamReader reads the events of an event stream, such as the body
+// of a response with content type EventStreamContentType.
+//
+//	events := aws.NewEventStreamReader(resp.Body)
+//	defer events.Close()
+//	for {
+//		event, err := events.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+type EventStreamReader struct {
+	// Maps event types to functions returning a pointer to decode the
+	// payload of such events into. The pointer becomes the event's Value.
+	// May be nil.
+	Events map[string]func() interface{}
+
+	r   io.ReadCloser
+	err error
+}
+
+func NewEventStreamReader(r io.ReadCloser) *EventStreamReader {
+	return &EventStreamReader{r: r}
+}
+
+// Next returns the next event of the stream. It returns io.EOF at the end of
+// the stream and an *EventStreamError for exception and error messages. Once
+// Next returns an error it returns the same error.
+func (r *EventStreamReader) Next() (*Event, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	event, err := r.next()
+	if err != nil {
+		r.err = err
+		return nil, err
+	}
+	return event, nil
+}
+
+func (r *EventStreamReader) next() (*Event, error) {
+	msg, err := ReadEventMessage(r.r)
+	if err != nil {
+		return nil, err
+	}
+	if err := eventStreamError(msg); err != nil {
+		return nil, err
+	}
+	event := &Event{
+		EventMessage: msg,
+		Type:         msg.headerString(":event-type"),
+		ContentType:  msg.headerString(":content-type"),
+	}
+	if newValue, ok := r.Events[event.Type]; ok {
+		event.Value = newValue()
+		if err := event.Decode(event.Value); err != nil {
+			return nil, fmt.Errorf("aws: decoding %s event: %v", event.Type, err)
+		}
+	}
+	return event, nil
+}
+
+// Close closes the underlying stream.
+func (r *EventStreamReader) Close() error {
+	return r.r.Close()
+}
+
+// EventStreamSigner signs the messages of an event stream request body.
+// Each message is wrapped in a message carrying its signature, which is
+// chained from the signature of the previous message, beginning with the
+// signature of the request. Not safe for concurrent use.
+type EventStreamSigner struct {
+	signer *Signer
+	creds  *Credentials
+	prior  string // hex encoded
+}
+
+// NewEventStreamSigner returns a signer for the body of req, which must
+// have been signed by signer with creds, with its X-Amz-Content-Sha256
+// header set to StreamingEventsPayload.
+func NewEventStreamSigner(signer *Signer, creds *Credentials, req *http.Request) (*EventStreamSigner, error) {
+	auth := req.Header.Get("Authorization")
+	i := strings.Index(auth, "Signature=")
+	if !strings.HasPrefix(auth, SignV4Algorithm) || i < 0 {
+		return nil, errors.New("aws: event stream request is not signed")
+	}
+	return &EventStreamSigner{signer, creds, auth[i+len("Signature="):]}, nil
+}
+
+// EventStreamSigner returns a signer for the event stream body of req, which
+// must have been signed by client with its X-Amz-Content-Sha256 header set
+// to StreamingEventsPayload.
+func (client *Client) EventStreamSigner(req *http.Request) (*EventStreamSigner, error) {
+	if client.Credentials == nil {
+		return nil, errors.New("aws: no credentials")
+	}
+	creds, err := client.Credentials.Retrieve()
+	if err != nil {
+		return nil, err
+	}
+	return NewEventStreamSigner(client.NewSigner(), creds, req)
+}
+
+// Sign returns a signed message with msg as its payload. A nil msg signs
+// the empty message which ends the stream.
+func (s *EventStreamSigner) Sign(msg *EventMessage) (*EventMessage, error) {
+	var payload []byte
+	if msg != nil {
+		var err error
+		payload, err = msg.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+	}
+	t := s.signer.now().Truncate(time.Millisecond)
+	date := EventHeader{":date", t}
+	headers, err := encodeEventHeaders([]EventHeader{date})
+	if err != nil {
+		return nil, err
+	}
+	headersHash := sha256.Sum256(headers)
+	payloadHash := sha256.Sum256(payload)
+	tosign := SignV4Algorithm + "-PAYLOAD"
+	tosign += "\n"
+	tosign += t.Format(sigv4TimeFormat)
+	tosign += "\n"
+	tosign += s.signer.scope(t)
+	tosign += "\n"
+	tosign += s.prior
+	tosign += "\n"
+	tosign += hex.EncodeToString(headersHash[:])
+	tosign += "\n"
+	tosign += hex.EncodeToString(payloadHash[:])
+	signature := hmacSHA256(s.signer.key(s.creds, t), tosign)
+	s.prior = hex.EncodeToString(signature)
+	return &EventMessage{
+		Headers: []EventHeader{date, {":chunk-signature", signature}},
+		Payload: payload,
+	}, nil
+}
+
+// EventStreamWriter writes an event stream, signing its messages if it has
+// a signer.
+type EventStreamWriter struct {
+	w      io.Writer
+	signer *EventStreamSigner
+}
+
+// NewEventStreamWriter returns a writer of messages to w. The signer may be
+// nil for unsigned streams.
+func NewEventStreamWriter(w io.Writer, signer *EventStreamSigner) *EventStreamWriter {
+	return &EventStreamWriter{w, signer}
+}
+
+// WriteEvent writes an event message with the given type, content type and
+// payload.
+func (w *EventStreamWriter) WriteEvent(eventType, contentType string, payload []byte) error {
+	return w.WriteMessage(&EventMessage{
+		Headers: []EventHeader{
+			{":message-type", "event"},
+			{":event-type", eventType},
+			{":content-type", contentType},
+		},
+		Payload: payload,
+	})
+}
+
+func (w *EventStreamWriter) WriteMessage(msg *EventMessage) error {
+	if w.signer != nil {
+		var err error
+		msg, err = w.signer.Sign(msg)
+		if err != nil {
+			return err
+		}
+	}
+	p, err := msg.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = w.w.Write(p)
+	return err
+}
+
+// Close writes the empty signed message which ends a signed stream, and
+// closes the underlying writer if it is an io.Closer.
+func (w *EventStreamWriter) Close() error {
+	if w.signer != nil {
+		msg, err := w.signer.Sign(nil)
+		if err != nil {
+			return err
+		}
+		p, err := msg.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if _, err := w.w.Write(p); err != nil {
+			return err
+		}
+	}
+	if closer, ok := w.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
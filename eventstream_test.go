@@ -0,0 +1,250 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEventMessage(t *testing.T, msg *EventMessage) []byte {
+	p, err := msg.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func testEvent(t *testing.T, headers ...string) *EventMessage {
+	msg := &EventMessage{Payload: []byte(headers[len(headers)-1])}
+	for i := 0; i+1 < len(headers); i += 2 {
+		msg.Headers = append(msg.Headers, EventHeader{headers[i], headers[i+1]})
+	}
+	return msg
+}
+
+// From the test suite of the event stream format.
+func TestEventMessageVector(t *testing.T) {
+	vector := []byte{
+		0x00, 0x00, 0x00, 0x1d, 0x00, 0x00, 0x00, 0x00, 0xfd, 0x52, 0x8c, 0x5a,
+		'{', '\'', 'f', 'o', 'o', '\'', ':', '\'', 'b', 'a', 'r', '\'', '}',
+		0xc3, 0x65, 0x39, 0x36,
+	}
+	msg := &EventMessage{Payload: []byte("{'foo':'bar'}")}
+	if p := testEventMessage(t, msg); !bytes.Equal(p, vector) {
+		t.Errorf("encoded % x", p)
+	}
+	decoded, err := ReadEventMessage(bytes.NewReader(vector))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Headers) != 0 || string(decoded.Payload) != "{'foo':'bar'}" {
+		t.Errorf("decoded %v %q", decoded.Headers, decoded.Payload)
+	}
+}
+
+func TestEventMessageHeaders(t *testing.T) {
+	msg := &EventMessage{
+		Headers: []EventHeader{
+			{"true", true},
+			{"false", false},
+			{"byte", int8(-8)},
+			{"short", int16(-16)},
+			{"int", int32(-32)},
+			{"long", int64(-64)},
+			{"bytes", []byte{0, 1, 2}},
+			{"empty", []byte{}},
+			{"string", "héllo"},
+			{"timestamp", time.Date(2013, 6, 26, 1, 2, 3, 4e6, time.UTC)},
+			{"uuid", [16]byte{0: 1, 15: 2}},
+		},
+		Payload: []byte("payload"),
+	}
+	decoded, err := ReadEventMessage(bytes.NewReader(testEventMessage(t, msg)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, msg) {
+		t.Errorf("decoded %#v", decoded)
+	}
+	if decoded.Header("string") != "héllo" || decoded.Header("missing") != nil {
+		t.Errorf("header lookup")
+	}
+
+	_, err = (&EventMessage{Headers: []EventHeader{{"int", 1}}}).MarshalBinary()
+	if err == nil {
+		t.Errorf("encoded a header of type int")
+	}
+}
+
+func TestReadEventMessageErrors(t *testing.T) {
+	p := testEventMessage(t, testEvent(t, ":event-type", "Records", "payload"))
+	if _, err := ReadEventMessage(bytes.NewReader(nil)); err != io.EOF {
+		t.Errorf("empty stream: %v", err)
+	}
+	if _, err := ReadEventMessage(bytes.NewReader(p[:len(p)-1])); err != io.ErrUnexpectedEOF {
+		t.Errorf("truncated message: %v", err)
+	}
+	for i, want := range map[int]string{
+		2:          "prelude crc32",
+		20:         "message crc32",
+		len(p) - 2: "message crc32",
+	} {
+		corrupt := append([]byte(nil), p...)
+		corrupt[i] ^= 0xff
+		if _, err := ReadEventMessage(bytes.NewReader(corrupt)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("corrupt byte %d: %v", i, err)
+		}
+	}
+}
+
+func TestEventStreamReader(t *testing.T) {
+	type stats struct {
+		BytesScanned int64 `xml:"BytesScanned"`
+	}
+	type record struct {
+		Data string `json:"data"`
+	}
+	var stream bytes.Buffer
+	for _, msg := range []*EventMessage{
+		testEvent(t, ":message-type", "event", ":event-type", "Records", ":content-type", "application/octet-stream", "a,b\n"),
+		testEvent(t, ":message-type", "event", ":event-type", "Stats", ":content-type", "text/xml", "<Stats><BytesScanned>42</BytesScanned></Stats>"),
+		testEvent(t, ":message-type", "event", ":event-type", "Record", ":content-type", "application/json", `{"data":"x"}`),
+		testEvent(t, ":message-type", "exception", ":exception-type", "ThrottlingException", ":content-type", "application/json", `{"message":"slow down"}`),
+	} {
+		stream.Write(testEventMessage(t, msg))
+	}
+	r := NewEventStreamReader(ioutil.NopCloser(&stream))
+	r.Events = map[string]func() interface{}{
+		"Stats":  func() interface{} { return new(stats) },
+		"Record": func() interface{} { return new(record) },
+	}
+
+	event, err := r.Next()
+	if err != nil || event.Type != "Records" || string(event.Payload) != "a,b\n" || event.Value != nil {
+		t.Fatalf("event %+v %v", event, err)
+	}
+	event, err = r.Next()
+	if err != nil || event.Type != "Stats" || !reflect.DeepEqual(event.Value, &stats{42}) {
+		t.Fatalf("event %+v %v", event, err)
+	}
+	event, err = r.Next()
+	if err != nil || !reflect.DeepEqual(event.Value, &record{"x"}) {
+		t.Fatalf("event %+v %v", event, err)
+	}
+	_, err = r.Next()
+	var apierr APIError
+	if !errors.Is(err, Code("ThrottlingException")) || !errors.As(err, &apierr) ||
+		apierr.ErrorMessage() != "slow down" || !apierr.Retryable() {
+		t.Errorf("exception %v", err)
+	}
+	if _, err2 := r.Next(); err2 != err {
+		t.Errorf("error after exception: %v", err2)
+	}
+
+	stream.Reset()
+	stream.Write(testEventMessage(t, testEvent(t, ":message-type", "error", ":error-code", "InternalError", ":error-message", "oops", "")))
+	r = NewEventStreamReader(ioutil.NopCloser(&stream))
+	_, err = r.Next()
+	var eserr *EventStreamError
+	if !errors.As(err, &eserr) || eserr.Exception || eserr.Code != "InternalError" || err.Error() != "aws: event stream: InternalError: oops" {
+		t.Errorf("error %v", err)
+	}
+
+	r = NewEventStreamReader(ioutil.NopCloser(&stream))
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("end of stream: %v", err)
+	}
+}
+
+func TestEventStreamSigner(t *testing.T) {
+	now := time.Date(2013, 6, 26, 1, 2, 3, 456789000, time.UTC)
+	signer := &Signer{Service: "transcribe", Region: USEast1, Now: func() time.Time { return now }}
+	creds := &Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}
+	req, _ := http.NewRequest("POST", "https://transcribestreaming.us-east-1.amazonaws.com/stream-transcription", nil)
+	if _, err := NewEventStreamSigner(signer, creds, req); err == nil {
+		t.Errorf("signed the body of an unsigned request")
+	}
+	req.Header.Set("X-Amz-Content-Sha256", StreamingEventsPayload)
+	if err := signer.Sign(creds, req); err != nil {
+		t.Fatal(err)
+	}
+	seed := req.Header.Get("Authorization")
+	seed = seed[strings.Index(seed, "Signature=")+len("Signature="):]
+
+	var body bytes.Buffer
+	es, err := NewEventStreamSigner(signer, creds, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewEventStreamWriter(&body, es)
+	if err := w.WriteEvent("AudioEvent", "application/octet-stream", []byte("audio")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	prior := seed
+	for i, want := range []*EventMessage{
+		testEvent(t, ":message-type", "event", ":event-type", "AudioEvent", ":content-type", "application/octet-stream", "audio"),
+		nil,
+	} {
+		msg, err := ReadEventMessage(&body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		date, _ := msg.Header(":date").(time.Time)
+		signature, _ := msg.Header(":chunk-signature").([]byte)
+		if !date.Equal(now.Truncate(time.Millisecond)) || len(signature) != sha256.Size {
+			t.Fatalf("message %d headers %v", i, msg.Headers)
+		}
+		var payload []byte
+		if want != nil {
+			payload = testEventMessage(t, want)
+		}
+		if !bytes.Equal(msg.Payload, payload) {
+			t.Errorf("message %d payload % x", i, msg.Payload)
+		}
+
+		dateHeader, _ := encodeEventHeaders(msg.Headers[:1])
+		headersHash := sha256.Sum256(dateHeader)
+		payloadHash := sha256.Sum256(payload)
+		tosign := "AWS4-HMAC-SHA256-PAYLOAD\n20130626T010203Z\n20130626/us-east-1/transcribe/aws4_request\n" +
+			prior + "\n" + hex.EncodeToString(headersHash[:]) + "\n" + hex.EncodeToString(payloadHash[:])
+		if want := hmacSHA256(signer.key(creds, now), tosign); !bytes.Equal(signature, want) {
+			t.Errorf("message %d signature %x (!= %x)", i, signature, want)
+		}
+		prior = hex.EncodeToString(signature)
+	}
+	if body.Len() != 0 {
+		t.Errorf("%d bytes after the end of the stream", body.Len())
+	}
+}
+
+func TestClientEventStreamSigner(t *testing.T) {
+	client := NewClient("transcribe", &Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}, &Region{Name: USWest2, Protocol: "https", Endpoint: "transcribestreaming.us-west-2.amazonaws.com"})
+	req, _ := http.NewRequest("POST", "https://transcribestreaming.us-west-2.amazonaws.com/stream-transcription", nil)
+	req.Header.Set("X-Amz-Content-Sha256", StreamingEventsPayload)
+	if err := client.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	es, err := client.EventStreamSigner(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if es.signer.Region != USWest2 || !strings.HasSuffix(req.Header.Get("Authorization"), es.prior) {
+		t.Errorf("signer %+v", es)
+	}
+}
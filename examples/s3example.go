@@ -39,7 +39,7 @@ func main() {
 		os.Exit(1)
 	}
 
-	creds := aws.Getenv()
+	creds := aws.DefaultProvider()
 	region := s3.USStandard
 	client := s3.NewClient(creds, region)
 	put, err := client.
@@ -0,0 +1,891 @@
+// Code generated by awsgen from api-2.json. DO NOT EDIT.
+
+// Package dynamodb is a client for Amazon DynamoDB.
+//
+// Amazon DynamoDB is a fully managed NoSQL database service that provides fast
+// and predictable performance with seamless scalability.
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+// The API version of the service model.
+const Version = "2012-08-10"
+
+const signingName = "dynamodb"
+
+const (
+	targetPrefix = "DynamoDB_20120810"
+	jsonVersion  = "1.0"
+)
+
+// Region returns the Amazon DynamoDB endpoint for the named region (e.g.
+// "us-west-2").
+func Region(name string) (*aws.Region, error) {
+	return aws.ResolveEndpoint("dynamodb", name)
+}
+
+// Client runs each API call through the phases of the embedded
+// *aws.Client, whose Handlers may be used to customize requests.
+type Client struct {
+	*aws.Client
+}
+
+// Credentials are retrieved from creds each time a request is signed. A
+// *aws.Credentials may be given for static keys.
+func NewClient(creds aws.CredentialsProvider, region *aws.Region) *Client {
+	client := aws.NewJSONClient(signingName, targetPrefix, jsonVersion, creds, region)
+	return &Client{client.Client}
+}
+
+type Request interface {
+	Request(*aws.Region) (*http.Request, error)
+}
+
+// Error is returned for responses with a status other than 2xx.
+type Error = aws.JSONError
+
+// Creates a signed request from req
+func (client *Client) Request(req Request) (*http.Request, error) {
+	hreq, err := req.Request(client.Region)
+	if err != nil {
+		return nil, err
+	}
+	err = client.Sign(hreq)
+	if err != nil {
+		return nil, err
+	}
+	return hreq, nil
+}
+
+func (client *Client) Do(req Request) (*http.Response, error) {
+	return client.DoContext(context.Background(), req)
+}
+
+// DoContext sends req, retrying failures according to client.Retryer. The
+// request is rebuilt and signed again for each attempt. Canceling ctx aborts
+// the request and any retries.
+func (client *Client) DoContext(ctx context.Context, req Request) (*http.Response, error) {
+	return client.run(ctx, req, nil)
+}
+
+// Sends req and decodes the response into output, which may be nil.
+func (client *Client) run(ctx context.Context, req Request, output interface{}) (*http.Response, error) {
+	op := &aws.Operation{
+		Context: ctx,
+		Name:    aws.OperationName(req),
+		Output:  output,
+		Build: func(op *aws.Operation) (err error) {
+			op.Request, err = req.Request(client.Region)
+			return err
+		},
+	}
+	if output != nil {
+		op.Unmarshal = func(op *aws.Operation) error {
+			return aws.DecodeJSONResponse(op.Response, op.Output)
+		}
+	}
+	err := client.Run(op)
+	return op.Response, err
+}
+
+// Error codes returned by Amazon DynamoDB. Errors match their code with
+// errors.Is.
+const (
+	ConditionalCheckFailedException        aws.Code = "ConditionalCheckFailedException"
+	InternalServerError                    aws.Code = "InternalServerError"
+	LimitExceededException                 aws.Code = "LimitExceededException"
+	ProvisionedThroughputExceededException aws.Code = "ProvisionedThroughputExceededException"
+	ResourceInUseException                 aws.Code = "ResourceInUseException"
+	ResourceNotFoundException              aws.Code = "ResourceNotFoundException"
+)
+
+// CreateTable is a request for the CreateTable API call.
+//
+// The CreateTable operation adds a new table to your account. Table names must
+// be unique within each Region.
+//
+// CreateTable is an asynchronous operation. Upon receiving a CreateTable
+// request, DynamoDB immediately returns a response with a TableStatus of
+// CREATING. After the table is created, DynamoDB sets the TableStatus to
+// ACTIVE.
+type CreateTable struct {
+	client *Client
+	input  CreateTableInput
+}
+
+// CreateTable returns a request for the CreateTable API call.
+func (client *Client) CreateTable(attributeDefinitions []AttributeDefinition, tableName string, keySchema []KeySchemaElement) *CreateTable {
+	request := &CreateTable{client: client}
+	request.input.AttributeDefinitions = attributeDefinitions
+	request.input.TableName = tableName
+	request.input.KeySchema = keySchema
+	return request
+}
+
+func (request *CreateTable) Exec() (*CreateTableOutput, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *CreateTable) ExecContext(ctx context.Context) (*CreateTableOutput, error) {
+	output := new(CreateTableOutput)
+	_, err := request.client.run(ctx, request, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (request *CreateTable) Request(region *aws.Region) (*http.Request, error) {
+	body, err := json.Marshal(&request.input)
+	if err != nil {
+		return nil, err
+	}
+	return aws.NewJSONRequest(region, targetPrefix+".CreateTable", jsonVersion, body)
+}
+
+// Controls how you are charged for read and write throughput and how you
+// manage capacity.
+func (request *CreateTable) BillingMode(billingMode BillingMode) *CreateTable {
+	request.input.BillingMode = billingMode
+	return request
+}
+
+// Represents the provisioned throughput settings for a specified table or
+// index.
+func (request *CreateTable) ProvisionedThroughput(provisionedThroughput *ProvisionedThroughput) *CreateTable {
+	request.input.ProvisionedThroughput = provisionedThroughput
+	return request
+}
+
+// A list of key-value pairs to label the table.
+func (request *CreateTable) Tags(tags ...Tag) *CreateTable {
+	request.input.Tags = tags
+	return request
+}
+
+// DeleteTable is a request for the DeleteTable API call.
+//
+// The DeleteTable operation deletes a table and all of its items. After a
+// DeleteTable request, the specified table is in the DELETING state until
+// DynamoDB completes the deletion.
+type DeleteTable struct {
+	client *Client
+	input  DeleteTableInput
+}
+
+// DeleteTable returns a request for the DeleteTable API call.
+func (client *Client) DeleteTable(tableName string) *DeleteTable {
+	request := &DeleteTable{client: client}
+	request.input.TableName = tableName
+	return request
+}
+
+func (request *DeleteTable) Exec() (*DeleteTableOutput, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *DeleteTable) ExecContext(ctx context.Context) (*DeleteTableOutput, error) {
+	output := new(DeleteTableOutput)
+	_, err := request.client.run(ctx, request, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (request *DeleteTable) Request(region *aws.Region) (*http.Request, error) {
+	body, err := json.Marshal(&request.input)
+	if err != nil {
+		return nil, err
+	}
+	return aws.NewJSONRequest(region, targetPrefix+".DeleteTable", jsonVersion, body)
+}
+
+// DescribeTable is a request for the DescribeTable API call.
+//
+// Returns information about the table, including the current status of the
+// table, when it was created, the primary key schema, and any indexes on the
+// table.
+type DescribeTable struct {
+	client *Client
+	input  DescribeTableInput
+}
+
+// DescribeTable returns a request for the DescribeTable API call.
+func (client *Client) DescribeTable(tableName string) *DescribeTable {
+	request := &DescribeTable{client: client}
+	request.input.TableName = tableName
+	return request
+}
+
+func (request *DescribeTable) Exec() (*DescribeTableOutput, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *DescribeTable) ExecContext(ctx context.Context) (*DescribeTableOutput, error) {
+	output := new(DescribeTableOutput)
+	_, err := request.client.run(ctx, request, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (request *DescribeTable) Request(region *aws.Region) (*http.Request, error) {
+	body, err := json.Marshal(&request.input)
+	if err != nil {
+		return nil, err
+	}
+	return aws.NewJSONRequest(region, targetPrefix+".DescribeTable", jsonVersion, body)
+}
+
+// WaitUntilTableExists calls DescribeTable until the TableExists waiter's
+// conditions are met, polling every 20s up to 25 times.
+func (request *DescribeTable) WaitUntilTableExists(ctx context.Context) error {
+	waiter := &aws.Waiter{
+		Name:        "TableExists",
+		Delay:       20 * time.Second,
+		MaxAttempts: 25,
+		Acceptors: []aws.WaiterAcceptor{
+			{State: aws.WaiterSuccess, Matcher: aws.MatchPath, Argument: "Table.TableStatus", Expected: "ACTIVE"},
+			{State: aws.WaiterRetry, Matcher: aws.MatchError, Expected: "ResourceNotFoundException"},
+		},
+	}
+	return waiter.Wait(ctx, func(ctx context.Context) (interface{}, error) {
+		return request.ExecContext(ctx)
+	})
+}
+
+// WaitUntilTableNotExists calls DescribeTable until the TableNotExists
+// waiter's conditions are met, polling every 20s up to 25 times.
+func (request *DescribeTable) WaitUntilTableNotExists(ctx context.Context) error {
+	waiter := &aws.Waiter{
+		Name:        "TableNotExists",
+		Delay:       20 * time.Second,
+		MaxAttempts: 25,
+		Acceptors: []aws.WaiterAcceptor{
+			{State: aws.WaiterSuccess, Matcher: aws.MatchError, Expected: "ResourceNotFoundException"},
+		},
+	}
+	return waiter.Wait(ctx, func(ctx context.Context) (interface{}, error) {
+		return request.ExecContext(ctx)
+	})
+}
+
+// GetItem is a request for the GetItem API call.
+//
+// The GetItem operation returns a set of attributes for the item with the
+// given primary key. If there is no matching item, GetItem does not return any
+// data and there will be no Item element in the response.
+type GetItem struct {
+	client *Client
+	input  GetItemInput
+}
+
+// GetItem returns a request for the GetItem API call.
+func (client *Client) GetItem(tableName string, key map[string]AttributeValue) *GetItem {
+	request := &GetItem{client: client}
+	request.input.TableName = tableName
+	request.input.Key = key
+	return request
+}
+
+func (request *GetItem) Exec() (*GetItemOutput, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *GetItem) ExecContext(ctx context.Context) (*GetItemOutput, error) {
+	output := new(GetItemOutput)
+	_, err := request.client.run(ctx, request, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (request *GetItem) Request(region *aws.Region) (*http.Request, error) {
+	body, err := json.Marshal(&request.input)
+	if err != nil {
+		return nil, err
+	}
+	return aws.NewJSONRequest(region, targetPrefix+".GetItem", jsonVersion, body)
+}
+
+// Determines the read consistency model: If set to true, then the operation
+// uses strongly consistent reads; otherwise, the operation uses eventually
+// consistent reads.
+func (request *GetItem) ConsistentRead(consistentRead bool) *GetItem {
+	request.input.ConsistentRead = &consistentRead
+	return request
+}
+
+// A string that identifies one or more attributes to retrieve from the table.
+func (request *GetItem) ProjectionExpression(projectionExpression string) *GetItem {
+	request.input.ProjectionExpression = projectionExpression
+	return request
+}
+
+// ListTables is a request for the ListTables API call.
+//
+// Returns an array of table names associated with the current account and
+// endpoint. The output from ListTables is paginated, with each page returning
+// a maximum of 100 table names.
+type ListTables struct {
+	client *Client
+	input  ListTablesInput
+}
+
+// ListTables returns a request for the ListTables API call.
+func (client *Client) ListTables() *ListTables {
+	request := &ListTables{client: client}
+	return request
+}
+
+func (request *ListTables) Exec() (*ListTablesOutput, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *ListTables) ExecContext(ctx context.Context) (*ListTablesOutput, error) {
+	output := new(ListTablesOutput)
+	_, err := request.client.run(ctx, request, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (request *ListTables) Request(region *aws.Region) (*http.Request, error) {
+	body, err := json.Marshal(&request.input)
+	if err != nil {
+		return nil, err
+	}
+	return aws.NewJSONRequest(region, targetPrefix+".ListTables", jsonVersion, body)
+}
+
+// The first table name that this operation will evaluate. Use the value that
+// was returned for LastEvaluatedTableName in a previous operation, so that you
+// can obtain the next page of results.
+func (request *ListTables) ExclusiveStartTableName(exclusiveStartTableName string) *ListTables {
+	request.input.ExclusiveStartTableName = exclusiveStartTableName
+	return request
+}
+
+// A maximum number of table names to return. If this parameter is not
+// specified, the limit is 100.
+func (request *ListTables) Limit(limit int) *ListTables {
+	request.input.Limit = &limit
+	return request
+}
+
+// Pages calls fn with each page of results until fn returns false or
+// there are no more pages.
+func (request *ListTables) Pages(fn func(*ListTablesOutput) bool) error {
+	return request.PagesContext(context.Background(), fn)
+}
+
+func (request *ListTables) PagesContext(ctx context.Context, fn func(*ListTablesOutput) bool) error {
+	page := *request
+	for {
+		output, err := page.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		if !fn(output) || output.LastEvaluatedTableName == "" {
+			return nil
+		}
+		page.input.ExclusiveStartTableName = output.LastEvaluatedTableName
+	}
+}
+
+// PutItem is a request for the PutItem API call.
+//
+// Creates a new item, or replaces an old item with a new item. If an item that
+// has the same primary key as the new item already exists in the specified
+// table, the new item completely replaces the existing item.
+type PutItem struct {
+	client *Client
+	input  PutItemInput
+}
+
+// PutItem returns a request for the PutItem API call.
+func (client *Client) PutItem(tableName string, item map[string]AttributeValue) *PutItem {
+	request := &PutItem{client: client}
+	request.input.TableName = tableName
+	request.input.Item = item
+	return request
+}
+
+func (request *PutItem) Exec() (*PutItemOutput, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *PutItem) ExecContext(ctx context.Context) (*PutItemOutput, error) {
+	output := new(PutItemOutput)
+	_, err := request.client.run(ctx, request, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (request *PutItem) Request(region *aws.Region) (*http.Request, error) {
+	body, err := json.Marshal(&request.input)
+	if err != nil {
+		return nil, err
+	}
+	return aws.NewJSONRequest(region, targetPrefix+".PutItem", jsonVersion, body)
+}
+
+// A condition that must be satisfied in order for a conditional PutItem
+// operation to succeed.
+func (request *PutItem) ConditionExpression(conditionExpression string) *PutItem {
+	request.input.ConditionExpression = conditionExpression
+	return request
+}
+
+// One or more values that can be substituted in an expression.
+func (request *PutItem) ExpressionAttributeValues(expressionAttributeValues map[string]AttributeValue) *PutItem {
+	request.input.ExpressionAttributeValues = expressionAttributeValues
+	return request
+}
+
+// Use ReturnValues if you want to get the item attributes as they appeared
+// before they were updated with the PutItem request.
+func (request *PutItem) ReturnValues(returnValues ReturnValue) *PutItem {
+	request.input.ReturnValues = returnValues
+	return request
+}
+
+// Query is a request for the Query API call.
+//
+// You must provide the name of the partition key attribute and a single value
+// for that attribute. Query returns all items with that partition key value.
+//
+// A single Query operation will read up to the maximum number of items set (if
+// using the Limit parameter) or a maximum of 1 MB of data. If LastEvaluatedKey
+// is present in the response, you will need to paginate the result set.
+type Query struct {
+	client *Client
+	input  QueryInput
+}
+
+// Query returns a request for the Query API call.
+func (client *Client) Query(tableName string) *Query {
+	request := &Query{client: client}
+	request.input.TableName = tableName
+	return request
+}
+
+func (request *Query) Exec() (*QueryOutput, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *Query) ExecContext(ctx context.Context) (*QueryOutput, error) {
+	output := new(QueryOutput)
+	_, err := request.client.run(ctx, request, output)
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (request *Query) Request(region *aws.Region) (*http.Request, error) {
+	body, err := json.Marshal(&request.input)
+	if err != nil {
+		return nil, err
+	}
+	return aws.NewJSONRequest(region, targetPrefix+".Query", jsonVersion, body)
+}
+
+// The condition that specifies the key values for items to be retrieved by the
+// Query action.
+func (request *Query) KeyConditionExpression(keyConditionExpression string) *Query {
+	request.input.KeyConditionExpression = keyConditionExpression
+	return request
+}
+
+// One or more values that can be substituted in an expression.
+func (request *Query) ExpressionAttributeValues(expressionAttributeValues map[string]AttributeValue) *Query {
+	request.input.ExpressionAttributeValues = expressionAttributeValues
+	return request
+}
+
+// Determines the read consistency model.
+func (request *Query) ConsistentRead(consistentRead bool) *Query {
+	request.input.ConsistentRead = &consistentRead
+	return request
+}
+
+// The maximum number of items to evaluate (not necessarily the number of
+// matching items).
+func (request *Query) Limit(limit int) *Query {
+	request.input.Limit = &limit
+	return request
+}
+
+// Specifies the order for index traversal: If true (default), the traversal is
+// performed in ascending order; if false, the traversal is performed in
+// descending order.
+func (request *Query) ScanIndexForward(scanIndexForward bool) *Query {
+	request.input.ScanIndexForward = &scanIndexForward
+	return request
+}
+
+// The primary key of the first item that this operation will evaluate. Use the
+// value that was returned for LastEvaluatedKey in the previous operation.
+func (request *Query) ExclusiveStartKey(exclusiveStartKey map[string]AttributeValue) *Query {
+	request.input.ExclusiveStartKey = exclusiveStartKey
+	return request
+}
+
+// Pages calls fn with each page of results until fn returns false or
+// there are no more pages.
+func (request *Query) Pages(fn func(*QueryOutput) bool) error {
+	return request.PagesContext(context.Background(), fn)
+}
+
+func (request *Query) PagesContext(ctx context.Context, fn func(*QueryOutput) bool) error {
+	page := *request
+	for {
+		output, err := page.ExecContext(ctx)
+		if err != nil {
+			return err
+		}
+		if !fn(output) || len(output.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		page.input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// Represents an attribute for describing the key schema for the table and
+// indexes.
+type AttributeDefinition struct {
+	// A name for the attribute.
+	AttributeName string `json:"AttributeName,omitempty"`
+
+	// The data type for the attribute.
+	AttributeType ScalarAttributeType `json:"AttributeType,omitempty"`
+}
+
+// Represents the data for an attribute. Each attribute value is described as a
+// name-value pair. The name is the data type, and the value is the data
+// itself.
+type AttributeValue struct {
+	// An attribute of type String.
+	S string `json:"S,omitempty"`
+
+	// An attribute of type Number. Numbers are sent as strings to maximize
+	// compatibility across languages and libraries.
+	N string `json:"N,omitempty"`
+
+	// An attribute of type Binary.
+	B []byte `json:"B,omitempty"`
+
+	// An attribute of type String Set.
+	SS []string `json:"SS,omitempty"`
+
+	// An attribute of type Map.
+	M map[string]AttributeValue `json:"M,omitempty"`
+
+	// An attribute of type List.
+	L []AttributeValue `json:"L,omitempty"`
+
+	// An attribute of type Null.
+	NULL *bool `json:"NULL,omitempty"`
+
+	// An attribute of type Boolean.
+	BOOL *bool `json:"BOOL,omitempty"`
+}
+
+// BillingMode is a string with one of the values below.
+type BillingMode string
+
+// Values of BillingMode.
+const (
+	BillingModeProvisioned   BillingMode = "PROVISIONED"
+	BillingModePayPerRequest BillingMode = "PAY_PER_REQUEST"
+)
+
+// CreateTableInput is the input of CreateTable.
+type CreateTableInput struct {
+	// An array of attributes that describe the key schema for the table and
+	// indexes.
+	AttributeDefinitions []AttributeDefinition `json:"AttributeDefinitions,omitempty"`
+
+	// The name of the table to create.
+	TableName string `json:"TableName,omitempty"`
+
+	// Specifies the attributes that make up the primary key for a table or an
+	// index.
+	KeySchema []KeySchemaElement `json:"KeySchema,omitempty"`
+
+	// Controls how you are charged for read and write throughput and how you
+	// manage capacity.
+	BillingMode BillingMode `json:"BillingMode,omitempty"`
+
+	// Represents the provisioned throughput settings for a specified table or
+	// index.
+	ProvisionedThroughput *ProvisionedThroughput `json:"ProvisionedThroughput,omitempty"`
+
+	// A list of key-value pairs to label the table.
+	Tags []Tag `json:"Tags,omitempty"`
+}
+
+// CreateTableOutput is the output of CreateTable.
+type CreateTableOutput struct {
+	// Represents the properties of the table.
+	TableDescription *TableDescription `json:"TableDescription,omitempty"`
+}
+
+// DeleteTableInput is the input of DeleteTable.
+type DeleteTableInput struct {
+	// The name of the table to delete.
+	TableName string `json:"TableName,omitempty"`
+}
+
+// DeleteTableOutput is the output of DeleteTable.
+type DeleteTableOutput struct {
+	// Represents the properties of a table.
+	TableDescription *TableDescription `json:"TableDescription,omitempty"`
+}
+
+// DescribeTableInput is the input of DescribeTable.
+type DescribeTableInput struct {
+	// The name of the table to describe.
+	TableName string `json:"TableName,omitempty"`
+}
+
+// DescribeTableOutput is the output of DescribeTable.
+type DescribeTableOutput struct {
+	// The properties of the table.
+	Table *TableDescription `json:"Table,omitempty"`
+}
+
+// GetItemInput is the input of GetItem.
+type GetItemInput struct {
+	// The name of the table containing the requested item.
+	TableName string `json:"TableName,omitempty"`
+
+	// A map of attribute names to AttributeValue objects, representing the
+	// primary key of the item to retrieve.
+	Key map[string]AttributeValue `json:"Key,omitempty"`
+
+	// Determines the read consistency model: If set to true, then the operation
+	// uses strongly consistent reads; otherwise, the operation uses eventually
+	// consistent reads.
+	ConsistentRead *bool `json:"ConsistentRead,omitempty"`
+
+	// A string that identifies one or more attributes to retrieve from the table.
+	ProjectionExpression string `json:"ProjectionExpression,omitempty"`
+}
+
+// GetItemOutput is the output of GetItem.
+type GetItemOutput struct {
+	// A map of attribute names to AttributeValue objects.
+	Item map[string]AttributeValue `json:"Item,omitempty"`
+}
+
+// Represents a single element of a key schema.
+type KeySchemaElement struct {
+	// The name of a key attribute.
+	AttributeName string `json:"AttributeName,omitempty"`
+
+	// The role that this key attribute will assume.
+	KeyType KeyType `json:"KeyType,omitempty"`
+}
+
+// KeyType is a string with one of the values below.
+type KeyType string
+
+// Values of KeyType.
+const (
+	KeyTypeHash  KeyType = "HASH"
+	KeyTypeRange KeyType = "RANGE"
+)
+
+// ListTablesInput is the input of ListTables.
+type ListTablesInput struct {
+	// The first table name that this operation will evaluate. Use the value that
+	// was returned for LastEvaluatedTableName in a previous operation, so that
+	// you can obtain the next page of results.
+	ExclusiveStartTableName string `json:"ExclusiveStartTableName,omitempty"`
+
+	// A maximum number of table names to return. If this parameter is not
+	// specified, the limit is 100.
+	Limit *int `json:"Limit,omitempty"`
+}
+
+// ListTablesOutput is the output of ListTables.
+type ListTablesOutput struct {
+	// The names of the tables associated with the current account at the current
+	// endpoint.
+	TableNames []string `json:"TableNames,omitempty"`
+
+	// The name of the last table in the current page of results. Use this value
+	// as the ExclusiveStartTableName in a new request to obtain the next page of
+	// results.
+	LastEvaluatedTableName string `json:"LastEvaluatedTableName,omitempty"`
+}
+
+// Represents the provisioned throughput settings for a specified table or
+// index.
+type ProvisionedThroughput struct {
+	// The maximum number of strongly consistent reads consumed per second before
+	// DynamoDB returns a ThrottlingException.
+	ReadCapacityUnits *int64 `json:"ReadCapacityUnits,omitempty"`
+
+	// The maximum number of writes consumed per second before DynamoDB returns a
+	// ThrottlingException.
+	WriteCapacityUnits *int64 `json:"WriteCapacityUnits,omitempty"`
+}
+
+// PutItemInput is the input of PutItem.
+type PutItemInput struct {
+	// The name of the table to contain the item.
+	TableName string `json:"TableName,omitempty"`
+
+	// A map of attribute name/value pairs, one for each attribute. Only the
+	// primary key attributes are required.
+	Item map[string]AttributeValue `json:"Item,omitempty"`
+
+	// A condition that must be satisfied in order for a conditional PutItem
+	// operation to succeed.
+	ConditionExpression string `json:"ConditionExpression,omitempty"`
+
+	// One or more values that can be substituted in an expression.
+	ExpressionAttributeValues map[string]AttributeValue `json:"ExpressionAttributeValues,omitempty"`
+
+	// Use ReturnValues if you want to get the item attributes as they appeared
+	// before they were updated with the PutItem request.
+	ReturnValues ReturnValue `json:"ReturnValues,omitempty"`
+}
+
+// PutItemOutput is the output of PutItem.
+type PutItemOutput struct {
+	// The attribute values as they appeared before the PutItem operation, if
+	// ReturnValues is ALL_OLD.
+	Attributes map[string]AttributeValue `json:"Attributes,omitempty"`
+}
+
+// QueryInput is the input of Query.
+type QueryInput struct {
+	// The name of the table containing the requested items.
+	TableName string `json:"TableName,omitempty"`
+
+	// The condition that specifies the key values for items to be retrieved by
+	// the Query action.
+	KeyConditionExpression string `json:"KeyConditionExpression,omitempty"`
+
+	// One or more values that can be substituted in an expression.
+	ExpressionAttributeValues map[string]AttributeValue `json:"ExpressionAttributeValues,omitempty"`
+
+	// Determines the read consistency model.
+	ConsistentRead *bool `json:"ConsistentRead,omitempty"`
+
+	// The maximum number of items to evaluate (not necessarily the number of
+	// matching items).
+	Limit *int `json:"Limit,omitempty"`
+
+	// Specifies the order for index traversal: If true (default), the traversal
+	// is performed in ascending order; if false, the traversal is performed in
+	// descending order.
+	ScanIndexForward *bool `json:"ScanIndexForward,omitempty"`
+
+	// The primary key of the first item that this operation will evaluate. Use
+	// the value that was returned for LastEvaluatedKey in the previous operation.
+	ExclusiveStartKey map[string]AttributeValue `json:"ExclusiveStartKey,omitempty"`
+}
+
+// QueryOutput is the output of Query.
+type QueryOutput struct {
+	// An array of item attributes that match the query criteria.
+	Items []map[string]AttributeValue `json:"Items,omitempty"`
+
+	// The number of items in the response.
+	Count *int `json:"Count,omitempty"`
+
+	// The number of items evaluated, before any QueryFilter is applied.
+	ScannedCount *int `json:"ScannedCount,omitempty"`
+
+	// The primary key of the item where the operation stopped, inclusive of the
+	// previous result set. If LastEvaluatedKey is empty, then the "last page" of
+	// results has been processed and there is no more data to be retrieved.
+	LastEvaluatedKey map[string]AttributeValue `json:"LastEvaluatedKey,omitempty"`
+}
+
+// ReturnValue is a string with one of the values below.
+type ReturnValue string
+
+// Values of ReturnValue.
+const (
+	ReturnValueNone       ReturnValue = "NONE"
+	ReturnValueAllOld     ReturnValue = "ALL_OLD"
+	ReturnValueUpdatedOld ReturnValue = "UPDATED_OLD"
+	ReturnValueAllNew     ReturnValue = "ALL_NEW"
+	ReturnValueUpdatedNew ReturnValue = "UPDATED_NEW"
+)
+
+// ScalarAttributeType is a string with one of the values below.
+type ScalarAttributeType string
+
+// Values of ScalarAttributeType.
+const (
+	ScalarAttributeTypeS ScalarAttributeType = "S"
+	ScalarAttributeTypeN ScalarAttributeType = "N"
+	ScalarAttributeTypeB ScalarAttributeType = "B"
+)
+
+// Represents the properties of a table.
+type TableDescription struct {
+	// An array of AttributeDefinition objects.
+	AttributeDefinitions []AttributeDefinition `json:"AttributeDefinitions,omitempty"`
+
+	// The name of the table.
+	TableName string `json:"TableName,omitempty"`
+
+	// The primary key structure for the table.
+	KeySchema []KeySchemaElement `json:"KeySchema,omitempty"`
+
+	// The current state of the table.
+	TableStatus TableStatus `json:"TableStatus,omitempty"`
+
+	// The date and time when the table was created, in UNIX epoch time format.
+	CreationDateTime *aws.UnixTime `json:"CreationDateTime,omitempty"`
+
+	// The number of items in the specified table.
+	ItemCount *int64 `json:"ItemCount,omitempty"`
+
+	// The Amazon Resource Name (ARN) that uniquely identifies the table.
+	TableArn string `json:"TableArn,omitempty"`
+}
+
+// TableStatus is a string with one of the values below.
+type TableStatus string
+
+// Values of TableStatus.
+const (
+	TableStatusCreating                          TableStatus = "CREATING"
+	TableStatusUpdating                          TableStatus = "UPDATING"
+	TableStatusDeleting                          TableStatus = "DELETING"
+	TableStatusActive                            TableStatus = "ACTIVE"
+	TableStatusInaccessibleEncryptionCredentials TableStatus = "INACCESSIBLE_ENCRYPTION_CREDENTIALS"
+	TableStatusArchiving                         TableStatus = "ARCHIVING"
+	TableStatusArchived                          TableStatus = "ARCHIVED"
+)
+
+// Describes a tag. A tag is a key-value pair.
+type Tag struct {
+	// The key of the tag.
+	Key string `json:"Key,omitempty"`
+
+	// The value of the tag.
+	Value string `json:"Value,omitempty"`
+}
@@ -0,0 +1,101 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+func testClient(t *testing.T, handler func(target string, input map[string]interface{}) (int, string)) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Error(err)
+		}
+		target := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), targetPrefix+".")
+		status, out := handler(target, input)
+		w.WriteHeader(status)
+		fmt.Fprint(w, out)
+	}))
+	t.Cleanup(server.Close)
+	region := &aws.Region{Name: "us-east-1", Protocol: "http", Endpoint: strings.TrimPrefix(server.URL, "http://")}
+	client := NewClient(&aws.Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}, region)
+	client.Retryer = nil
+	return client
+}
+
+func TestListTablesPages(t *testing.T) {
+	client := testClient(t, func(target string, input map[string]interface{}) (int, string) {
+		if target != "ListTables" || input["Limit"] != 2.0 {
+			t.Errorf("%s %v", target, input)
+		}
+		switch input["ExclusiveStartTableName"] {
+		case nil:
+			return 200, `{"TableNames":["a","b"],"LastEvaluatedTableName":"b"}`
+		case "b":
+			return 200, `{"TableNames":["c"]}`
+		}
+		return 400, `{"__type":"ValidationException","message":"bad start"}`
+	})
+	var names []string
+	err := client.ListTables().Limit(2).Pages(func(page *ListTablesOutput) bool {
+		names = append(names, page.TableNames...)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "a,b,c" {
+		t.Errorf("tables: %q", names)
+	}
+}
+
+func TestGetItem(t *testing.T) {
+	client := testClient(t, func(target string, input map[string]interface{}) (int, string) {
+		if target != "GetItem" || input["TableName"] != "t" || input["ConsistentRead"] != true {
+			t.Errorf("%s %v", target, input)
+		}
+		return 200, `{"Item":{"id":{"S":"1"},"n":{"N":"42"}}}`
+	})
+	output, err := client.GetItem("t", map[string]AttributeValue{"id": {S: "1"}}).ConsistentRead(true).Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Item["n"].N != "42" {
+		t.Errorf("item: %v", output.Item)
+	}
+}
+
+func TestError(t *testing.T) {
+	client := testClient(t, func(target string, input map[string]interface{}) (int, string) {
+		return 400, `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"no table"}`
+	})
+	_, err := client.DescribeTable("t").Exec()
+	if !errors.Is(err, ResourceNotFoundException) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.Message != "no table" {
+		t.Errorf("unexpected error: %#v", err)
+	}
+}
+
+func TestWaitUntilTableExists(t *testing.T) {
+	client := testClient(t, func(target string, input map[string]interface{}) (int, string) {
+		return 200, `{"Table":{"TableName":"t","TableStatus":"ACTIVE"}}`
+	})
+	if err := client.DescribeTable("t").WaitUntilTableExists(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
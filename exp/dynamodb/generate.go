@@ -0,0 +1,7 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dynamodb
+
+//go:generate go run ../../cmd/awsgen -model api-2.json -paginators paginators-1.json -waiters waiters-2.json -o api.go
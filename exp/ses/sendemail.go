@@ -7,7 +7,7 @@
 /*
 a wrapper around the aws ses api.
 
-	creds := &aws.Credentials{AccessKeyId, SecretAccessKey}
+	creds := aws.DefaultProvider()
 	result, err := ses.NewSendEmailRequest().
 		To("exàmple@example.com").
 		Source("noreply@example.com").
@@ -20,35 +20,64 @@ a wrapper around the aws ses api.
 	}
 	log.Print("Sent email (SES MessageId %s)", result.MessageId)
 
+To configure retries, metrics, tracing, the HTTP client or the User-Agent
+of requests, send them with a Client instead of Exec.
+
+	client := ses.NewClient(creds, region)
+	result, err := client.SendEmail(req)
 */
 package ses
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
-	"net/http"
+	"mime"
 	"net/url"
 	"strings"
-	"time"
 
 	"github.com/bmatsuo/go-aws"
 )
 
+// Region returns the SES endpoint for the named region (e.g. "us-west-2").
+func Region(name string) (*aws.Region, error) {
+	return aws.ResolveEndpoint("email", name)
+}
+
 type SendEmailResult struct {
 	MessageId string `xml:"SendEmailResult>MessageId"`
 	*ResponseMetadata
 }
 
-type ResponseMetadata struct {
-	RequestId string
+type ResponseMetadata = aws.ResponseMetadata
+
+// Sends the requests of Exec, so that they share a connection pool.
+var execHTTPClient = aws.NewHTTPClient(nil)
+
+// Client runs each API call through the phases of the embedded
+// *aws.Client, whose Handlers may be used to customize requests.
+type Client struct {
+	*aws.Client
 }
 
+// Credentials are retrieved from creds each time a request is signed. A
+// *aws.Credentials may be given for static keys.
+func NewClient(creds aws.CredentialsProvider, region *aws.Region) *Client {
+	client := aws.NewClient("email", creds, region)
+	client.ParseError = aws.QueryErrorParser("ses")
+	return &Client{client}
+}
+
+// Error is returned for responses with a status other than 2xx.
+type Error = aws.QueryError
+
 type SendEmailRequest struct {
-	*Destination
+	*Destination     `query:"Destination"`
 	ReplyToAddresses []string
 	ReturnPath       string
 	Source           string
-	*Message
+	*Message         `query:"Message"`
+	region           *aws.Region
 }
 
 func NewSendEmailRequest() *SendEmailRequest {
@@ -95,70 +124,116 @@ func (req *SendEmailRequest) Html(data string) *SendEmailRequest {
 	req.Message.Body.Html = &MessageContent{"UTF-8", data}
 	return req
 }
-func (req SendEmailRequest) Exec(creds aws.Credentials) error {
-	dest := req.Destination
-	msg := req.Message
-	now := time.Now().UTC()
-
-	var numparams = 3 // authentication
-	numparams += len(dest.ToAddresses) + len(dest.CcAddresses) + len(dest.BccAddresses)
-	numparams += len(req.ReplyToAddresses) + 2
-	numparams += 5
-	params := make(url.Values, numparams)
 
-	params.Set("AWSAccessKeyId", creds.AccessKeyId)
-	params.Set("Action", "SendEmail")
-	params.Set("Timestamp", now.Format(time.RFC3339))
+// Region sets the SES endpoint. By default the region named by $AWS_REGION
+// or $AWS_DEFAULT_REGION is used, or us-east-1 if neither is set.
+func (req *SendEmailRequest) Region(region *aws.Region) *SendEmailRequest {
+	req.region = region
+	return req
+}
 
-	for i := range dest.ToAddresses {
-		name := fmt.Sprintf("Destination.ToAddresses.member.%d", i+1)
-		params.Set(name, UTF8BEncodedWord(dest.ToAddresses[i]))
+func (req *SendEmailRequest) endpoint() (*aws.Region, error) {
+	if req.region != nil {
+		return req.region, nil
 	}
-	for i := range dest.CcAddresses {
-		name := fmt.Sprintf("Destination.CcAddresses.member.%d", i+1)
-		params.Set(name, UTF8BEncodedWord(dest.ToAddresses[i]))
-	}
-	for i := range dest.BccAddresses {
-		name := fmt.Sprintf("Destination.BccAddresses.member.%d", i+1)
-		params.Set(name, UTF8BEncodedWord(dest.ToAddresses[i]))
+	name := aws.RegionFromEnv()
+	if name == "" {
+		name = aws.USEast1
 	}
+	return Region(name)
+}
 
-	for i := range req.ReplyToAddresses {
-		name := fmt.Sprintf("ReplyToAddresses.member.%d", i+1)
-		params.Set(name, UTF8BEncodedWord(req.ReplyToAddresses[i]))
-	}
-	if req.ReturnPath != "" {
-		params.Set("ReturnPath", req.ReturnPath)
+// Exec sends the email using credentials retrieved from creds. A
+// *aws.Credentials may be given for static keys. Requests sent by Exec
+// share one HTTP client.
+func (req SendEmailRequest) Exec(creds aws.CredentialsProvider) (*SendEmailResult, error) {
+	return req.ExecContext(context.Background(), creds)
+}
+
+// ExecContext is like Exec but canceling ctx aborts the request and any
+// retries.
+func (req SendEmailRequest) ExecContext(ctx context.Context, creds aws.CredentialsProvider) (*SendEmailResult, error) {
+	region, err := req.endpoint()
+	if err != nil {
+		return nil, err
 	}
-	params.Set("Source", UTF8BEncodedWord(req.Source))
+	client := NewClient(creds, region)
+	client.HTTPClient = execHTTPClient
+	return client.SendEmailContext(ctx, &req)
+}
+
+// SendEmail sends the email described by req. The region set by
+// req.Region is ignored.
+func (client *Client) SendEmail(req *SendEmailRequest) (*SendEmailResult, error) {
+	return client.SendEmailContext(context.Background(), req)
+}
 
-	params.Set("Message.Subject.Charset", msg.Subject.Charset)
-	params.Set("Message.Subject.Data", msg.Subject.Data)
-	if msg.Body.Text != nil {
-		params.Set("Message.Body.Text.Charset", msg.Body.Text.Charset)
-		params.Set("Message.Body.Text.Data", msg.Body.Text.Data)
+// SendEmailContext is like SendEmail but canceling ctx aborts the request
+// and any retries.
+func (client *Client) SendEmailContext(ctx context.Context, req *SendEmailRequest) (*SendEmailResult, error) {
+	params, err := req.params()
+	if err != nil {
+		return nil, err
 	}
-	if msg.Body.Html != nil {
-		params.Set("Message.Body.Html.Charset", msg.Body.Html.Charset)
-		params.Set("Message.Body.Html.Data", msg.Body.Html.Data)
+	op := &aws.Operation{
+		Context: ctx,
+		Name:    "SendEmail",
+		Output:  new(SendEmailResult),
+		Build: func(op *aws.Operation) (err error) {
+			op.Request, err = aws.NewQueryRequest(client.Region, params)
+			return err
+		},
+		Unmarshal: func(op *aws.Operation) error {
+			return aws.DecodeQueryResponse(op.Response, op.Output)
+		},
 	}
-
-	host := "email.us-east-1.amazonaws.com"
-	urlstr := "https://" + host + "/SendEmail"
-	body := params.Encode()
-	httpreq, err := http.NewRequest("POST", urlstr, strings.NewReader(body))
+	err = client.Run(op)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return op.Output.(*SendEmailResult), nil
+}
 
-	httpreq.Header.Set("Date", now.Format(time.RFC1123))
-	httpreq.Header.Set("Host", host)
-	httpreq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	httpreq.Header.Set("X-Amzn-Authorization",
-		fmt.Sprintf("AWS3 AWSAccessKeyID=%s, Signature=%s, Algorithm=%s, SignedHeaders=%s",
-			creds.AccessKeyId, "needtodothis", "HmacSHA256", "Date;Host"))
+// Addresses are sent verbatim, except that a non-ASCII display name (as in
+// "Name <addr>") is sent as an RFC 2047 encoded word.
+func (req *SendEmailRequest) params() (url.Values, error) {
+	encoded := *req
+	if req.Destination != nil {
+		encoded.Destination = &Destination{
+			BccAddresses: encodeAddresses(req.BccAddresses),
+			CcAddresses:  encodeAddresses(req.CcAddresses),
+			ToAddresses:  encodeAddresses(req.ToAddresses),
+		}
+	}
+	encoded.ReplyToAddresses = encodeAddresses(req.ReplyToAddresses)
+	encoded.Source = encodeAddress(req.Source)
 
-	return nil
+	params := make(url.Values)
+	params.Set("Action", "SendEmail")
+	params.Set("Version", "2010-12-01")
+	err := aws.EncodeQuery(params, &encoded)
+	return params, err
+}
+
+func encodeAddresses(addrs []string) []string {
+	var encoded []string
+	for _, addr := range addrs {
+		encoded = append(encoded, encodeAddress(addr))
+	}
+	return encoded
+}
+
+func encodeAddress(addr string) string {
+	i := strings.LastIndex(addr, "<")
+	if i <= 0 || !strings.HasSuffix(addr, ">") {
+		return addr
+	}
+	name := strings.Trim(strings.TrimSpace(addr[:i]), `"`)
+	word := mime.BEncoding.Encode("UTF-8", name)
+	if word == name {
+		return addr // ASCII names need no encoding
+	}
+	return word + " " + addr[i:]
 }
 
 type Destination struct {
@@ -0,0 +1,119 @@
+package ses
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+// A hand-written fake of the SES SendEmail action, which accepts mail only
+// from go-aws@example.com. It is not a recording of SES.
+type fakeSES struct{}
+
+func (fakeSES) RoundTrip(req *http.Request) (*http.Response, error) {
+	defer req.Body.Close()
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	params, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	code, xml := 200, `<SendEmailResponse xmlns="http://ses.amazonaws.com/doc/2010-12-01/">
+  <SendEmailResult><MessageId>fake-message-id</MessageId></SendEmailResult>
+  <ResponseMetadata><RequestId>fake-request-id</RequestId></ResponseMetadata>
+</SendEmailResponse>`
+	switch {
+	case !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 "):
+		code, xml = 403, `<ErrorResponse><Error><Type>Sender</Type><Code>MissingAuthenticationToken</Code><Message>Request is missing Authentication Token</Message></Error><RequestId>fake-request-id</RequestId></ErrorResponse>`
+	case params.Get("Action") != "SendEmail":
+		code, xml = 400, `<ErrorResponse><Error><Type>Sender</Type><Code>InvalidAction</Code><Message>Unexpected action</Message></Error><RequestId>fake-request-id</RequestId></ErrorResponse>`
+	case params.Get("Source") != "go-aws@example.com":
+		code, xml = 400, `<ErrorResponse><Error><Type>Sender</Type><Code>MessageRejected</Code><Message>Email address is not verified.</Message></Error><RequestId>fake-request-id</RequestId></ErrorResponse>`
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/xml"}},
+		Body:          ioutil.NopCloser(strings.NewReader(xml)),
+		ContentLength: int64(len(xml)),
+		Request:       req,
+	}, nil
+}
+
+func TestSendEmail(t *testing.T) {
+	region, err := Region(aws.USEast1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(&aws.Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}, region)
+	client.HTTPClient = aws.NewHTTPClient(&aws.HTTPOptions{Transport: fakeSES{}})
+
+	req := NewSendEmailRequest().
+		To("success@simulator.amazonses.com").
+		Sender("go-aws@example.com").
+		Subject("Welcome").
+		Text("Hello, example!")
+	result, err := client.SendEmail(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.MessageId == "" || result.RequestId == "" {
+		t.Errorf("result %+v", result)
+	}
+
+	_, err = client.SendEmail(req.Sender("unverified@example.com"))
+	var seserr *Error
+	if !errors.As(err, &seserr) || !errors.Is(err, aws.Code("MessageRejected")) || seserr.StatusCode != 400 {
+		t.Errorf("unverified sender: %v", err)
+	}
+}
+
+func TestSendEmailParams(t *testing.T) {
+	req := NewSendEmailRequest().
+		To("to@example.com").
+		Cc("cc1@example.com", "cc2@example.com").
+		Bcc("bcc@example.com").
+		ReplyTo("Reply <reply@example.com>").
+		Sender("Jörg <noreply@example.com>").
+		Subject("Welcome").
+		Text("Hello")
+	params, err := req.params()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, value := range map[string]string{
+		"Action":                            "SendEmail",
+		"Destination.ToAddresses.member.1":  "to@example.com",
+		"Destination.CcAddresses.member.1":  "cc1@example.com",
+		"Destination.CcAddresses.member.2":  "cc2@example.com",
+		"Destination.BccAddresses.member.1": "bcc@example.com",
+		"ReplyToAddresses.member.1":         "Reply <reply@example.com>",
+		"Source":                            "=?UTF-8?b?SsO2cmc=?= <noreply@example.com>",
+		"Message.Subject.Charset":           "UTF-8",
+		"Message.Subject.Data":              "Welcome",
+		"Message.Body.Text.Data":            "Hello",
+		"Message.Body.Html.Data":            "",
+		"Destination.CcAddresses.member.3":  "",
+		"ReturnPath":                        "",
+		"Message.Body.Text.Charset":         "UTF-8",
+		"Version":                           "2010-12-01",
+	} {
+		if params.Get(name) != value {
+			t.Errorf("%s=%q (expected %q)", name, params.Get(name), value)
+		}
+	}
+	if req.ToAddresses[0] != "to@example.com" {
+		t.Errorf("request modified: %q", req.ToAddresses[0])
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The sections of an ini-style file, as used by the shared credentials file.
+type iniFile map[string]map[string]string
+
+func readIniFile(filename string) (iniFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ini, err := parseIni(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	return ini, nil
+}
+
+// Keys indented under a key with an empty value form a sub-section and are
+// stored as "key.subkey".
+//
+//	[profile dev]
+//	s3 =
+//	    addressing_style = path
+func parseIni(r io.Reader) (iniFile, error) {
+	ini := make(iniFile)
+	var section map[string]string
+	var parent string
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("line %d: unterminated section header", lineno)
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			section = ini[name]
+			if section == nil {
+				section = make(map[string]string)
+				ini[name] = section
+			}
+			parent = ""
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineno)
+		}
+		if section == nil {
+			return nil, fmt.Errorf("line %d: key outside of a section", lineno)
+		}
+		key := strings.TrimSpace(line[:eq])
+		value := strings.TrimSpace(line[eq+1:])
+		indented := raw[0] == ' ' || raw[0] == '\t'
+		switch {
+		case indented && parent != "":
+			section[parent+"."+key] = value
+		case value == "":
+			parent = key
+		default:
+			parent = ""
+			section[key] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ini, nil
+}
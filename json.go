@@ -0,0 +1,259 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONClient calls services which use the JSON protocol (e.g. DynamoDB,
+// Kinesis and KMS). Each call POSTs its input as JSON to the root of the
+// endpoint, naming the API call in the X-Amz-Target header.
+//
+//	client := aws.NewJSONClient("dynamodb", "DynamoDB_20120810", "1.0", creds, region)
+//	var output ListTablesOutput
+//	err := client.Call("ListTables", &ListTablesInput{Limit: 10}, &output)
+type JSONClient struct {
+	*Client
+
+	TargetPrefix string // e.g. "DynamoDB_20120810" or "Kinesis_20131202"
+	JSONVersion  string // "1.0" or "1.1"
+}
+
+// NewJSONClient returns a client which signs as signingName. Its responses
+// are validated against any X-Amz-Crc32 header.
+func NewJSONClient(signingName, targetPrefix, jsonVersion string, creds CredentialsProvider, region *Region) *JSONClient {
+	client := NewClient(signingName, creds, region)
+	parseError := JSONErrorParser(signingName)
+	client.ParseError = func(resp *http.Response) error {
+		if err := parseError(resp); err != nil {
+			return err
+		}
+		return CheckCRC32(resp)
+	}
+	return &JSONClient{client, targetPrefix, jsonVersion}
+}
+
+// Call sends input to the named API call and decodes the response into
+// output. input is marshaled with encoding/json and may be nil for calls
+// without parameters. output may be nil to discard the response.
+func (client *JSONClient) Call(name string, input, output interface{}) error {
+	return client.CallContext(context.Background(), name, input, output)
+}
+
+// CallContext is like Call but canceling ctx aborts the request and any
+// retries.
+func (client *JSONClient) CallContext(ctx context.Context, name string, input, output interface{}) error {
+	body := []byte("{}")
+	if input != nil {
+		var err error
+		body, err = json.Marshal(input)
+		if err != nil {
+			return err
+		}
+	}
+	op := &Operation{
+		Context: ctx,
+		Name:    name,
+		Output:  output,
+		Build: func(op *Operation) (err error) {
+			target := client.TargetPrefix + "." + name
+			op.Request, err = NewJSONRequest(client.Region, target, client.JSONVersion, body)
+			return err
+		},
+	}
+	if output != nil {
+		op.Unmarshal = func(op *Operation) error {
+			return DecodeJSONResponse(op.Response, op.Output)
+		}
+	}
+	err := client.Run(op)
+	if err == nil && output == nil {
+		op.Response.Body.Close()
+	}
+	return err
+}
+
+// NewJSONRequest returns a JSON protocol request, which POSTs body to the
+// root of the region's endpoint.
+func NewJSONRequest(region *Region, target, jsonVersion string, body []byte) (*http.Request, error) {
+	uri := region.Url("", "/", nil)
+	req, err := http.NewRequest("POST", uri.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-"+jsonVersion)
+	req.Header.Set("X-Amz-Target", target)
+	return req, nil
+}
+
+// DecodeJSONResponse reads the body of a successful JSON protocol response
+// into v and closes it. An empty body leaves v unchanged.
+func DecodeJSONResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, v)
+}
+
+// JSONError is the error returned by JSON protocol services. It implements
+// APIError.
+type JSONError struct {
+	Service    string // prefixes the error message (e.g. "dynamodb")
+	Code       string
+	Message    string
+	RequestId  string
+	StatusCode int
+}
+
+func (err *JSONError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", err.Service, err.Code, err.Message)
+}
+
+func (err *JSONError) Is(target error) bool { return IsCode(err.Code, target) }
+func (err *JSONError) ErrorCode() string    { return err.Code }
+func (err *JSONError) ErrorMessage() string { return err.Message }
+func (err *JSONError) RequestID() string    { return err.RequestId }
+func (err *JSONError) HostID() string       { return "" }
+func (err *JSONError) HTTPStatusCode() int  { return err.StatusCode }
+func (err *JSONError) Retryable() bool      { return RetryableError(err.Code, err.StatusCode) }
+
+// JSONErrorParser returns a Client.ParseError function for JSON protocol
+// services. The code is taken from the body's __type (or code) field, or
+// from the X-Amzn-ErrorType header, without its namespace:
+// "com.amazonaws.dynamodb.v20120810#ResourceNotFoundException" has the
+// code "ResourceNotFoundException".
+func JSONErrorParser(service string) func(resp *http.Response) error {
+	return func(resp *http.Response) error {
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		err := &JSONError{
+			Service:    service,
+			RequestId:  resp.Header.Get("X-Amzn-Requestid"),
+			StatusCode: resp.StatusCode,
+		}
+		var shape struct {
+			Type         string `json:"__type"`
+			Code         string `json:"code"`
+			Message      string `json:"message"`
+			MessageUpper string `json:"Message"`
+		}
+		if json.Unmarshal(body, &shape) == nil {
+			err.Code = shape.Type
+			if err.Code == "" {
+				err.Code = shape.Code
+			}
+			err.Message = shape.Message
+			if err.Message == "" {
+				err.Message = shape.MessageUpper
+			}
+		} else {
+			err.Message = strings.TrimSpace(string(body))
+		}
+		if err.Code == "" {
+			err.Code = resp.Header.Get("X-Amzn-Errortype")
+		}
+		err.Code = trimErrorType(err.Code)
+		if err.Code == "" {
+			err.Code = StatusErrorCode(resp.StatusCode)
+		}
+		return err
+	}
+}
+
+// Removes the namespace and any suffix (e.g. ":http://internal.amazon.com/")
+// from an error type.
+func trimErrorType(errorType string) string {
+	if i := strings.Index(errorType, ":"); i >= 0 {
+		errorType = errorType[:i]
+	}
+	if i := strings.LastIndex(errorType, "#"); i >= 0 {
+		errorType = errorType[i+1:]
+	}
+	return errorType
+}
+
+// The error code of a ChecksumError.
+const CRC32CheckFailed = "CRC32CheckFailed"
+
+// ChecksumError is returned when a response body does not match its
+// X-Amz-Crc32 header. The request is retried by default.
+type ChecksumError struct {
+	Expected uint32
+	Actual   uint32
+}
+
+func (err *ChecksumError) Error() string {
+	return fmt.Sprintf("aws: response crc32 %d does not match header %d", err.Actual, err.Expected)
+}
+
+func (err *ChecksumError) ErrorCode() string { return CRC32CheckFailed }
+
+// CheckCRC32 reads resp's body and returns a *ChecksumError if it does not
+// match the response's X-Amz-Crc32 header, which DynamoDB sends. The body is
+// replaced so it may be read again. Responses without the header, and those
+// decompressed by the http package, are not checked.
+func CheckCRC32(resp *http.Response) error {
+	header := resp.Header.Get("X-Amz-Crc32")
+	if header == "" || resp.Uncompressed {
+		return nil
+	}
+	expected, err := strconv.ParseUint(header, 10, 32)
+	if err != nil {
+		return fmt.Errorf("aws: invalid X-Amz-Crc32 header %q", header)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if actual := crc32.ChecksumIEEE(body); actual != uint32(expected) {
+		return &ChecksumError{uint32(expected), actual}
+	}
+	return nil
+}
+
+// UnixTime is a timestamp which JSON protocol services send as seconds
+// since the Unix epoch.
+type UnixTime struct {
+	time.Time
+}
+
+func (t UnixTime) MarshalJSON() ([]byte, error) {
+	secs := float64(t.UnixNano()) / float64(time.Second)
+	return []byte(strconv.FormatFloat(secs, 'f', -1, 64)), nil
+}
+
+func (t *UnixTime) UnmarshalJSON(p []byte) error {
+	if string(p) == "null" {
+		return nil
+	}
+	secs, err := strconv.ParseFloat(string(p), 64)
+	if err != nil {
+		return fmt.Errorf("aws: invalid timestamp %s", p)
+	}
+	whole := math.Floor(secs)
+	t.Time = time.Unix(int64(whole), int64((secs-whole)*float64(time.Second))).UTC()
+	return nil
+}
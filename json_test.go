@@ -0,0 +1,160 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testJSONClient(t *testing.T, handler http.HandlerFunc) *JSONClient {
+	base := testServiceClient(t, handler)
+	client := NewJSONClient("dynamodb", "DynamoDB_20120810", "1.0", base.Credentials, base.Region)
+	client.Retryer = base.Retryer
+	return client
+}
+
+func TestJSONClientCall(t *testing.T) {
+	client := testJSONClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Method != "POST" || r.URL.Path != "/":
+			t.Errorf("%s %s", r.Method, r.URL.Path)
+		case r.Header.Get("Content-Type") != "application/x-amz-json-1.0":
+			t.Errorf("content type %q", r.Header.Get("Content-Type"))
+		case !strings.Contains(r.Header.Get("Authorization"), "/dynamodb/aws4_request"):
+			t.Errorf("authorization %q", r.Header.Get("Authorization"))
+		}
+		switch target := r.Header.Get("X-Amz-Target"); target {
+		case "DynamoDB_20120810.ListTables":
+			if string(body) != `{"Limit":2}` {
+				t.Errorf("body %s", body)
+			}
+			out := `{"TableNames":["a","b"]}`
+			w.Header().Set("X-Amz-Crc32", fmt.Sprint(crc32.ChecksumIEEE([]byte(out))))
+			fmt.Fprint(w, out)
+		case "DynamoDB_20120810.DescribeLimits":
+			if string(body) != `{}` {
+				t.Errorf("body %s", body)
+			}
+			fmt.Fprint(w, `{}`)
+		default:
+			w.Header().Set("X-Amzn-RequestId", "req-1")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ResourceNotFoundException","message":"no table"}`)
+		}
+	})
+
+	var output struct{ TableNames []string }
+	err := client.Call("ListTables", struct{ Limit int }{2}, &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.TableNames) != 2 || output.TableNames[1] != "b" {
+		t.Errorf("output %+v", output)
+	}
+
+	if err := client.Call("DescribeLimits", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.Call("DescribeTable", struct{ TableName string }{"c"}, &output)
+	var jsonerr *JSONError
+	if !errors.As(err, &jsonerr) {
+		t.Fatalf("error %#v", err)
+	}
+	if jsonerr.Code != "ResourceNotFoundException" || jsonerr.Message != "no table" || jsonerr.RequestId != "req-1" || jsonerr.StatusCode != 400 {
+		t.Errorf("error %+v", jsonerr)
+	}
+	if !errors.Is(err, Code("ResourceNotFoundException")) {
+		t.Errorf("%v is not ResourceNotFoundException", err)
+	}
+	if err.Error() != "dynamodb: ResourceNotFoundException: no table" {
+		t.Errorf("message %q", err.Error())
+	}
+}
+
+func TestJSONErrorParser(t *testing.T) {
+	parse := JSONErrorParser("svc")
+	for _, test := range []struct {
+		status  int
+		header  string
+		body    string
+		code    string
+		message string
+	}{
+		{400, "", `{"__type":"ThrottlingException","Message":"slow down"}`, "ThrottlingException", "slow down"},
+		{400, "", `{"code":"ValidationException","message":"bad"}`, "ValidationException", "bad"},
+		{403, "AccessDeniedException:http://internal.amazon.com/coral/com.amazon.coral.service/", "{}", "AccessDeniedException", ""},
+		{503, "", "unavailable", "ServiceUnavailable", "unavailable"},
+	} {
+		resp := &http.Response{
+			StatusCode: test.status,
+			Header:     http.Header{"X-Amzn-Errortype": {test.header}},
+			Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+		}
+		err, ok := parse(resp).(*JSONError)
+		if !ok {
+			t.Errorf("error %#v", err)
+			continue
+		}
+		if err.Code != test.code || err.Message != test.message {
+			t.Errorf("error %+v", err)
+		}
+	}
+}
+
+func TestCheckCRC32(t *testing.T) {
+	attempts := 0
+	client := testJSONClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		out := `{"Count":1}`
+		crc := crc32.ChecksumIEEE([]byte(out))
+		if attempts == 1 {
+			crc++
+		}
+		w.Header().Set("X-Amz-Crc32", fmt.Sprint(crc))
+		fmt.Fprint(w, out)
+	})
+	var output struct{ Count int }
+	if err := client.Call("Scan", nil, &output); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 || output.Count != 1 {
+		t.Errorf("%d attempts; output %+v", attempts, output)
+	}
+
+	client.Retryer = nil
+	attempts = 0
+	err := client.Call("Scan", nil, &output)
+	var crcerr *ChecksumError
+	if !errors.As(err, &crcerr) || crcerr.Expected != crcerr.Actual+1 {
+		t.Errorf("error %#v", err)
+	}
+}
+
+func TestUnixTime(t *testing.T) {
+	var v struct{ Created UnixTime }
+	if err := json.Unmarshal([]byte(`{"Created":1372680000.5}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if expect := time.Date(2013, 7, 1, 12, 0, 0, 5e8, time.UTC); !v.Created.Equal(expect) {
+		t.Errorf("time %v (expected %v)", v.Created, expect)
+	}
+	p, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(p) != `{"Created":1372680000.5}` {
+		t.Errorf("json %s", p)
+	}
+}
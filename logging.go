@@ -0,0 +1,241 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Logger receives debugging output. A *log.Logger may be used.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// The number of bytes of each body logged when RequestLogger.MaxBody is zero.
+const DefaultMaxLogBody = 1024
+
+const redacted = "REDACTED"
+
+// The values of credentials in XML and JSON response bodies, such as those
+// returned by STS. A value cut off at the end of a truncated body matches.
+var credentialElements = regexp.MustCompile(
+	`(<(AccessKeyId|SecretAccessKey|SessionToken)>)[^<]*(</|$)` +
+		`|("(AccessKeyId|SecretAccessKey|SessionToken|Token)"\s*:\s*")[^"]*("|$)`)
+
+// RedactCredentials replaces the values of credentials in an XML or JSON
+// response body with "REDACTED".
+func RedactCredentials(body []byte) []byte {
+	return credentialElements.ReplaceAll(body, []byte("${1}${4}"+redacted+"${3}${6}"))
+}
+
+// Headers, and query and form parameters, whose values are never logged.
+var redactedHeaders = map[string]bool{
+	"Authorization":        true,
+	"X-Amz-Security-Token": true,
+}
+
+var redactedParams = map[string]bool{
+	"x-amz-signature":      true,
+	"x-amz-security-token": true,
+	"x-amz-credential":     true,
+	"signature":            true,
+
+	// STS parameters
+	"webidentitytoken": true,
+	"samlassertion":    true,
+	"tokencode":        true,
+	"serialnumber":     true,
+	"policy":           true,
+}
+
+// RequestLogger logs the string to sign, request and response of each
+// attempt. Credentials, tokens and signatures are redacted. It is a SendPhase
+// middleware and is not used unless added to a client's Handlers.
+//
+//	logger := &aws.RequestLogger{Logger: log.Default()}
+//	client.Handlers.Add(aws.SendPhase, "log", logger.Middleware)
+//
+// When an error carries the string to sign the service computed (e.g.
+// *s3.Error), the difference with the client's is logged as well.
+type RequestLogger struct {
+	Logger  Logger
+	LogBody bool // log request and response bodies
+	MaxBody int  // bytes of each body logged; DefaultMaxLogBody if zero
+}
+
+// The string to sign echoed by a service after a signature mismatch.
+type stringToSigner interface {
+	StringToSign() string
+}
+
+func (l *RequestLogger) Middleware(next Handler) Handler {
+	return func(op *Operation) error {
+		prefix := fmt.Sprintf("aws: %s %s attempt %d", op.Service, op.Name, op.Attempt)
+		if op.StringToSign != "" {
+			l.Logger.Printf("%s: string to sign:\n%s", prefix, op.StringToSign)
+		}
+		l.Logger.Printf("%s: request:\n%s", prefix, l.dumpRequest(op.Request))
+		err := next(op)
+		if op.Response != nil {
+			l.Logger.Printf("%s: response:\n%s", prefix, l.dumpResponse(op.Response, err == nil))
+		}
+		if err != nil {
+			l.Logger.Printf("%s: error: %v", prefix, err)
+			var echo stringToSigner
+			if errors.As(err, &echo) && echo.StringToSign() != "" && op.StringToSign != "" {
+				l.Logger.Printf("%s: string to sign differs from the service's (-client +service):\n%s",
+					prefix, DiffLines(op.StringToSign, echo.StringToSign()))
+			}
+		}
+		return err
+	}
+}
+
+func (l *RequestLogger) maxBody() int {
+	if l.MaxBody > 0 {
+		return l.MaxBody
+	}
+	return DefaultMaxLogBody
+}
+
+func (l *RequestLogger) dumpRequest(req *http.Request) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s %s\n", req.Method, RedactURL(req.URL).RequestURI(), "HTTP/1.1")
+	fmt.Fprintf(&buf, "Host: %s\n", req.URL.Host)
+	writeHeader(&buf, req.Header)
+	if l.LogBody && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err == nil {
+			if isForm(req.Header) {
+				l.writeBody(&buf, redactForm(body))
+			} else {
+				l.writeBody(&buf, body)
+			}
+			body.Close()
+		}
+	}
+	return buf.String()
+}
+
+func isForm(header http.Header) bool {
+	mediatype, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	return mediatype == "application/x-www-form-urlencoded"
+}
+
+// Reads a form encoded body, redacting sensitive parameters.
+func redactForm(body io.Reader) io.Reader {
+	p, err := ioutil.ReadAll(body)
+	if err != nil {
+		return bytes.NewReader(p)
+	}
+	form, err := url.ParseQuery(string(p))
+	if err != nil || !redactValues(form) {
+		return bytes.NewReader(p)
+	}
+	return strings.NewReader(form.Encode())
+}
+
+// The body of a response is only logged when ok because error responses
+// have already been read.
+func (l *RequestLogger) dumpResponse(resp *http.Response, ok bool) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n", resp.Proto, resp.Status)
+	writeHeader(&buf, resp.Header)
+	if l.LogBody && ok && resp.Body != nil {
+		prefix := make([]byte, l.maxBody()+1)
+		n, _ := io.ReadFull(resp.Body, prefix)
+		prefix = prefix[:n]
+		l.writeBody(&buf, bytes.NewReader(RedactCredentials(prefix)))
+		resp.Body = readCloser{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+	}
+	return buf.String()
+}
+
+func (l *RequestLogger) writeBody(buf *bytes.Buffer, body io.Reader) {
+	p := make([]byte, l.maxBody()+1)
+	n, _ := io.ReadFull(body, p)
+	buf.WriteString("\n")
+	if n > l.maxBody() {
+		buf.Write(p[:l.maxBody()])
+		buf.WriteString("\n[truncated]\n")
+		return
+	}
+	buf.Write(p[:n])
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func writeHeader(buf *bytes.Buffer, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range header[key] {
+			if redactedHeaders[http.CanonicalHeaderKey(key)] {
+				value = redacted
+			}
+			fmt.Fprintf(buf, "%s: %s\n", key, value)
+		}
+	}
+}
+
+// RedactURL returns a copy of u without the signature and credentials of a
+// presigned URL.
+func RedactURL(u *url.URL) *url.URL {
+	c := *u
+	query := c.Query()
+	if redactValues(query) {
+		c.RawQuery = query.Encode()
+	}
+	return &c
+}
+
+// Replaces the values of redactedParams, returning true if any were found.
+func redactValues(values url.Values) bool {
+	changed := false
+	for key := range values {
+		if redactedParams[strings.ToLower(key)] {
+			values[key] = []string{redacted}
+			changed = true
+		}
+	}
+	return changed
+}
+
+// DiffLines compares a and b line by line, prefixing lines only in a with
+// "-" and lines only in b with "+".
+func DiffLines(a, b string) string {
+	alines := strings.Split(a, "\n")
+	blines := strings.Split(b, "\n")
+	var buf bytes.Buffer
+	for i := 0; i < len(alines) || i < len(blines); i++ {
+		switch {
+		case i >= len(blines):
+			fmt.Fprintf(&buf, "-%s\n", alines[i])
+		case i >= len(alines):
+			fmt.Fprintf(&buf, "+%s\n", blines[i])
+		case alines[i] == blines[i]:
+			fmt.Fprintf(&buf, " %s\n", alines[i])
+		default:
+			fmt.Fprintf(&buf, "-%s\n+%s\n", alines[i], blines[i])
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type testSignatureError struct{ tosign string }
+
+func (err *testSignatureError) Error() string        { return "SignatureDoesNotMatch" }
+func (err *testSignatureError) StringToSign() string { return err.tosign }
+
+func TestRequestLogger(t *testing.T) {
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Request-Id", "REQID")
+		if r.URL.Query().Get("fail") != "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("response body which is long"))
+	})
+	client.Credentials = &Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET", SessionToken: "TOKEN"}
+	client.ParseError = func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusForbidden {
+			resp.Body.Close()
+			return &testSignatureError{"AWS4-HMAC-SHA256\n20130524T000000Z\nother"}
+		}
+		return nil
+	}
+	var buf bytes.Buffer
+	logger := &RequestLogger{Logger: log.New(&buf, "", 0), LogBody: true, MaxBody: 8}
+	client.Handlers.Add(SendPhase, "log", logger.Middleware)
+
+	op := testOperation(client)
+	build := op.Build
+	op.Build = func(op *Operation) error {
+		err := build(op)
+		if err == nil {
+			op.Request.URL.RawQuery = "X-Amz-Signature=presigned&list-type=2"
+		}
+		return err
+	}
+	if err := client.Run(op); err != nil {
+		t.Fatal(err)
+	}
+	if op.Output != "response body which is long" {
+		t.Errorf("response body %q", op.Output)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"string to sign:\nAWS4-HMAC-SHA256\n",
+		op.StringToSign,
+		"GET /?X-Amz-Signature=REDACTED&list-type=2 HTTP/1.1\n",
+		"Authorization: REDACTED\n",
+		"X-Amz-Security-Token: REDACTED\n",
+		"200 OK\n",
+		"X-Amz-Request-Id: REQID\n",
+		"response\n[truncated]\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	for _, secret := range []string{"TOKEN", "presigned", "Credential=", "SECRET"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %q:\n%s", secret, out)
+		}
+	}
+
+	buf.Reset()
+	op = testOperation(client)
+	build = op.Build
+	op.Build = func(op *Operation) error {
+		err := build(op)
+		if err == nil {
+			op.Request.URL.RawQuery = "fail=1"
+		}
+		return err
+	}
+	err := client.Run(op)
+	var sigerr *testSignatureError
+	if !errors.As(err, &sigerr) {
+		t.Fatalf("error %v", err)
+	}
+	lines := strings.Split(op.StringToSign, "\n")
+	want := " AWS4-HMAC-SHA256\n-" + lines[1] + "\n+20130524T000000Z\n-" + lines[2] + "\n+other\n-" + lines[3] + "\n"
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("log missing diff %q:\n%s", want, buf.String())
+	}
+}
+
+func TestRedactCredentials(t *testing.T) {
+	for body, want := range map[string]string{
+		`<Credentials><AccessKeyId>ASIA</AccessKeyId><SessionToken>TOKEN</SessionToken></Credentials>`: `<Credentials><AccessKeyId>REDACTED</AccessKeyId><SessionToken>REDACTED</SessionToken></Credentials>`,
+		`{"AccessKeyId": "ASIA", "SecretAccessKey":"SECRET", "Other": "x"}`:                            `{"AccessKeyId": "REDACTED", "SecretAccessKey":"REDACTED", "Other": "x"}`,
+		`<SecretAccessKey>SECR`: `<SecretAccessKey>REDACTED`,
+	} {
+		if redacted := string(RedactCredentials([]byte(body))); redacted != want {
+			t.Errorf("%s redacted to %s", body, redacted)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics receives a measurement of every request a client sends. It must
+// be safe for concurrent use. A request whose response body is never closed
+// is never reported.
+type Metrics interface {
+	ObserveAttempt(m *AttemptMetrics)
+}
+
+// AttemptMetrics describe one request. Requests with a response are
+// reported when the response body is closed.
+type AttemptMetrics struct {
+	Service   string
+	Operation string
+	Attempt   int // counting from one; later attempts are retries
+
+	// Time until the response headers were received.
+	Latency time.Duration
+
+	StatusCode int    // zero without a response
+	ErrorCode  string // the service error code or "" on success
+	Err        error
+	Throttled  bool
+
+	BytesSent     int64 // request body bytes
+	BytesReceived int64 // response body bytes read
+}
+
+// The error code used for attempts which failed without an API error
+// (e.g. network errors).
+const ClientErrorCode = "ClientError"
+
+func newAttemptMetrics(op *Operation) *AttemptMetrics {
+	m := &AttemptMetrics{
+		Service:   op.Service,
+		Operation: op.Name,
+		Attempt:   op.Attempt,
+	}
+	if op.Request.ContentLength > 0 {
+		m.BytesSent = op.Request.ContentLength
+	}
+	return m
+}
+
+func (m *AttemptMetrics) setResult(resp *http.Response, err error) {
+	m.Err = err
+	m.Throttled = IsThrottle(resp, err)
+	if resp != nil {
+		m.StatusCode = resp.StatusCode
+	}
+	if err != nil {
+		m.ErrorCode = ClientErrorCode
+		var coder ErrorCoder
+		if errors.As(err, &coder) {
+			m.ErrorCode = coder.ErrorCode()
+		}
+	}
+}
+
+// Counts the bytes read and reports the attempt once it is closed and the
+// result of the attempt is known.
+type meteredBody struct {
+	io.ReadCloser
+	m *AttemptMetrics
+
+	mu     sync.Mutex
+	closed bool
+	report func(*AttemptMetrics)
+	once   sync.Once
+}
+
+func (body *meteredBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.m.BytesReceived += int64(n)
+	return n, err
+}
+
+func (body *meteredBody) Close() error {
+	err := body.ReadCloser.Close()
+	body.mu.Lock()
+	body.closed = true
+	report := body.report
+	body.mu.Unlock()
+	if report != nil {
+		body.once.Do(func() { report(body.m) })
+	}
+	return err
+}
+
+func (body *meteredBody) reportTo(report func(*AttemptMetrics)) {
+	body.mu.Lock()
+	body.report = report
+	closed := body.closed
+	body.mu.Unlock()
+	if closed {
+		body.once.Do(func() { report(body.m) })
+	}
+}
+
+// Latency histogram bucket upper bounds, in seconds.
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// ExpvarMetrics publishes counters for each operation in an expvar.Map,
+// with keys of the form "s3.GetObject.attempts".
+type ExpvarMetrics struct {
+	Vars *expvar.Map
+}
+
+// NewExpvarMetrics publishes a map with the given name. It panics if the
+// name is already used, like expvar.NewMap.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	return &ExpvarMetrics{expvar.NewMap(name)}
+}
+
+func (e *ExpvarMetrics) ObserveAttempt(m *AttemptMetrics) {
+	prefix := m.Service + "." + m.Operation + "."
+	e.Vars.Add(prefix+"attempts", 1)
+	if m.Attempt > 1 {
+		e.Vars.Add(prefix+"retries", 1)
+	}
+	if m.ErrorCode != "" {
+		e.Vars.Add(prefix+"errors", 1)
+		e.Vars.Add(prefix+"errors."+m.ErrorCode, 1)
+	}
+	if m.Throttled {
+		e.Vars.Add(prefix+"throttles", 1)
+	}
+	e.Vars.Add(prefix+"bytes_sent", m.BytesSent)
+	e.Vars.Add(prefix+"bytes_received", m.BytesReceived)
+	e.Vars.AddFloat(prefix+"latency_seconds_sum", m.Latency.Seconds())
+	for _, le := range DefaultLatencyBuckets {
+		if m.Latency.Seconds() <= le {
+			e.Vars.Add(prefix+"latency_seconds_le_"+formatFloat(le), 1)
+		}
+	}
+}
+
+// PrometheusMetrics collects metrics in memory and serves them in the
+// Prometheus text exposition format.
+//
+//	metrics := aws.NewPrometheusMetrics()
+//	client.Metrics = metrics
+//	http.Handle("/metrics", metrics)
+//
+// Attempts are counted when their response bodies are closed, so responses
+// which are never closed are missing from the metrics.
+type PrometheusMetrics struct {
+	// Latency buckets in seconds; DefaultLatencyBuckets if nil. Copied on
+	// first use, after which changes are ignored.
+	Buckets []float64
+
+	mu         sync.Mutex
+	bounds     []float64 // the buckets in use
+	operations map[operationKey]*operationMetrics
+}
+
+type operationKey struct{ service, operation string }
+
+type operationMetrics struct {
+	attempts, retries, throttles int64
+	bytesSent, bytesReceived     int64
+	errors                       map[string]int64
+	buckets                      []int64
+	latencySum                   float64
+}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{}
+}
+
+// Must be called with p.mu held.
+func (p *PrometheusMetrics) buckets() []float64 {
+	if p.bounds == nil {
+		p.bounds = DefaultLatencyBuckets
+		if p.Buckets != nil {
+			p.bounds = append([]float64{}, p.Buckets...)
+		}
+	}
+	return p.bounds
+}
+
+func (p *PrometheusMetrics) ObserveAttempt(m *AttemptMetrics) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.operations == nil {
+		p.operations = make(map[operationKey]*operationMetrics)
+	}
+	key := operationKey{m.Service, m.Operation}
+	om := p.operations[key]
+	if om == nil {
+		om = &operationMetrics{
+			errors:  make(map[string]int64),
+			buckets: make([]int64, len(p.buckets())),
+		}
+		p.operations[key] = om
+	}
+	om.attempts++
+	if m.Attempt > 1 {
+		om.retries++
+	}
+	if m.Throttled {
+		om.throttles++
+	}
+	if m.ErrorCode != "" {
+		om.errors[m.ErrorCode]++
+	}
+	om.bytesSent += m.BytesSent
+	om.bytesReceived += m.BytesReceived
+	om.latencySum += m.Latency.Seconds()
+	for i, le := range p.buckets() {
+		if m.Latency.Seconds() <= le {
+			om.buckets[i]++
+		}
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (p *PrometheusMetrics) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	keys := make([]operationKey, 0, len(p.operations))
+	for key := range p.operations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].service != keys[j].service {
+			return keys[i].service < keys[j].service
+		}
+		return keys[i].operation < keys[j].operation
+	})
+	var buf strings.Builder
+	counter := func(name, help string, value func(*operationMetrics) int64) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, key := range keys {
+			fmt.Fprintf(&buf, "%s{%s} %d\n", name, key.labels(), value(p.operations[key]))
+		}
+	}
+	counter("aws_client_attempts_total", "Requests sent, including retries.",
+		func(om *operationMetrics) int64 { return om.attempts })
+	counter("aws_client_retries_total", "Requests which were retries.",
+		func(om *operationMetrics) int64 { return om.retries })
+	counter("aws_client_throttles_total", "Requests rejected by throttling.",
+		func(om *operationMetrics) int64 { return om.throttles })
+	counter("aws_client_sent_bytes_total", "Request body bytes sent.",
+		func(om *operationMetrics) int64 { return om.bytesSent })
+	counter("aws_client_received_bytes_total", "Response body bytes received.",
+		func(om *operationMetrics) int64 { return om.bytesReceived })
+
+	name := "aws_client_errors_total"
+	fmt.Fprintf(&buf, "# HELP %s Failed requests by error code.\n# TYPE %s counter\n", name, name)
+	for _, key := range keys {
+		om := p.operations[key]
+		codes := make([]string, 0, len(om.errors))
+		for code := range om.errors {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Fprintf(&buf, "%s{%s,code=%s} %d\n", name, key.labels(), quoteLabel(code), om.errors[code])
+		}
+	}
+
+	name = "aws_client_attempt_duration_seconds"
+	fmt.Fprintf(&buf, "# HELP %s Time until response headers were received.\n# TYPE %s histogram\n", name, name)
+	for _, key := range keys {
+		om := p.operations[key]
+		for i, le := range p.buckets() {
+			fmt.Fprintf(&buf, "%s_bucket{%s,le=\"%s\"} %d\n", name, key.labels(), formatFloat(le), om.buckets[i])
+		}
+		fmt.Fprintf(&buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, key.labels(), om.attempts)
+		fmt.Fprintf(&buf, "%s_sum{%s} %s\n", name, key.labels(), formatFloat(om.latencySum))
+		fmt.Fprintf(&buf, "%s_count{%s} %d\n", name, key.labels(), om.attempts)
+	}
+	p.mu.Unlock()
+	n, err := io.WriteString(w, buf.String())
+	return int64(n), err
+}
+
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+func (key operationKey) labels() string {
+	return "service=" + quoteLabel(key.service) + ",operation=" + quoteLabel(key.operation)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
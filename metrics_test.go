@@ -0,0 +1,113 @@
+package aws
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	attempts := 0
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("hello"))
+	})
+	client.ParseError = func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			resp.Body.Close()
+			return testCodedError("SlowDown")
+		}
+		return nil
+	}
+	prom := NewPrometheusMetrics()
+	expvars := NewExpvarMetrics("aws_test_metrics")
+	var observed []*AttemptMetrics
+	client.Metrics = testMetrics(func(m *AttemptMetrics) {
+		observed = append(observed, m)
+		prom.ObserveAttempt(m)
+		expvars.ObserveAttempt(m)
+	})
+
+	op := testOperation(client)
+	build := op.Build
+	op.Build = func(op *Operation) error {
+		err := build(op)
+		if err == nil {
+			op.Request.Method = "PUT"
+			op.Request.Body = http.NoBody
+			op.Request.ContentLength = 3
+		}
+		return err
+	}
+	if err := client.Run(op); err != nil {
+		t.Fatal(err)
+	}
+	if len(observed) != 2 {
+		t.Fatalf("%d attempts observed", len(observed))
+	}
+	if m := observed[0]; m.ErrorCode != "SlowDown" || !m.Throttled || m.StatusCode != 503 || m.Attempt != 1 {
+		t.Errorf("first attempt %+v", m)
+	}
+	if m := observed[1]; m.ErrorCode != "" || m.BytesReceived != 5 || m.BytesSent != 3 || m.Operation != "Test" || m.Service != "test" {
+		t.Errorf("second attempt %+v", m)
+	}
+
+	var buf bytes.Buffer
+	prom.WriteTo(&buf)
+	for _, want := range []string{
+		"# TYPE aws_client_attempts_total counter\n",
+		`aws_client_attempts_total{service="test",operation="Test"} 2` + "\n",
+		`aws_client_retries_total{service="test",operation="Test"} 1` + "\n",
+		`aws_client_throttles_total{service="test",operation="Test"} 1` + "\n",
+		`aws_client_received_bytes_total{service="test",operation="Test"} 5` + "\n",
+		`aws_client_errors_total{service="test",operation="Test",code="SlowDown"} 1` + "\n",
+		`aws_client_attempt_duration_seconds_bucket{service="test",operation="Test",le="+Inf"} 2` + "\n",
+		`aws_client_attempt_duration_seconds_count{service="test",operation="Test"} 2` + "\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	for key, want := range map[string]string{
+		"test.Test.attempts":              "2",
+		"test.Test.retries":               "1",
+		"test.Test.errors.SlowDown":       "1",
+		"test.Test.bytes_received":        "5",
+		"test.Test.latency_seconds_le_30": "2",
+	} {
+		if v := expvars.Vars.Get(key); v == nil || v.String() != want {
+			t.Errorf("%s = %v", key, v)
+		}
+	}
+}
+
+type testMetrics func(m *AttemptMetrics)
+
+func (f testMetrics) ObserveAttempt(m *AttemptMetrics) { f(m) }
+
+func TestPrometheusBuckets(t *testing.T) {
+	metrics := NewPrometheusMetrics()
+	metrics.Buckets = []float64{0.1}
+	metrics.ObserveAttempt(&AttemptMetrics{Service: "s", Operation: "Op", Attempt: 1, Latency: time.Millisecond})
+	metrics.Buckets = []float64{0.1, 1, 10}
+	var buf strings.Builder
+	if _, err := metrics.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `le="0.1"} 1`) || strings.Contains(buf.String(), `le="10"`) {
+		t.Errorf("histogram:\n%s", buf.String())
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if q := quoteLabel("a\"b\\c\nd"); q != `"a\"b\\c\nd"` {
+		t.Errorf("quoted %s", q)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The longest time a presigned URL may be valid.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// Presign returns req's URL with a query string signature (Signature
+// Version 4) which is valid for the expires duration, capped at
+// MaxPresignExpiry. Headers of req other than those never signed (e.g.
+// User-Agent) are signed, so they are returned and must be sent by whoever
+// uses the URL. req is not modified, except that a body which cannot be
+// reread (req.GetBody is nil) is hashed and replaced with an equal one.
+//
+// The payload is signed as for Sign, so a body is hashed unless the signer
+// has DisablePayloadSigning set or req has an X-Amz-Content-Sha256 header.
+func (signer *Signer) Presign(creds *Credentials, req *http.Request, expires time.Duration) (*url.URL, http.Header, error) {
+	if expires < time.Second {
+		return nil, nil, fmt.Errorf("aws: presign expiry %v is less than 1s", expires)
+	}
+	if expires > MaxPresignExpiry {
+		expires = MaxPresignExpiry
+	}
+	if creds.Expired() {
+		return nil, nil, ErrCredentialsExpired
+	}
+	t := signer.now()
+
+	// Sign a copy of the request with the signature's parameters added to
+	// its query.
+	u := *req.URL
+	preq := *req
+	preq.URL = &u
+	preq.Header = req.Header.Clone()
+	if preq.Header == nil {
+		preq.Header = make(http.Header)
+	}
+	preq.Header.Del("Authorization")
+	preq.Header.Del("X-Amz-Date")
+	preq.Header.Del("X-Amz-Security-Token")
+	payload, err := signer.payloadHash(&preq)
+	req.Body = preq.Body // buffered by payloadHash if it could not be reread
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope := signer.scope(t)
+	_, signed := canonicalHeaders(&preq)
+	query := u.Query()
+	query.Set("X-Amz-Algorithm", SignV4Algorithm)
+	query.Set("X-Amz-Credential", creds.AccessKeyId+"/"+scope)
+	query.Set("X-Amz-Date", t.Format(sigv4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", signed)
+	if creds.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	query.Del("X-Amz-Signature")
+	u.RawQuery = query.Encode()
+
+	creq, _ := signer.canonicalRequest(&preq, payload)
+	tosign := signer.stringToSign(t, scope, creq)
+	signature := hex.EncodeToString(hmacSHA256(signer.key(creds, t), tosign))
+	u.RawQuery += "&X-Amz-Signature=" + signature
+
+	header := make(http.Header)
+	for name, values := range preq.Header {
+		if !sigv4IgnoredHeaders[strings.ToLower(name)] {
+			header[name] = values
+		}
+	}
+	return &u, header, nil
+}
+
+// Presign returns a presigned URL for req, signed with the client's
+// credentials and clock. See Signer.Presign.
+func (client *Client) Presign(req *http.Request, expires time.Duration) (*url.URL, http.Header, error) {
+	if client.Credentials == nil {
+		return nil, nil, fmt.Errorf("aws: no credentials")
+	}
+	creds, err := client.Credentials.Retrieve()
+	if err != nil {
+		return nil, nil, err
+	}
+	return client.NewSigner().Presign(creds, req, expires)
+}
@@ -0,0 +1,108 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// The default time allowed for a credential_process command to run.
+const DefaultProcessTimeout = time.Minute
+
+// ProcessProvider runs an external command implementing the
+// credential_process protocol and parses the JSON it writes to stdout.
+// Credentials are cached and refreshed ExpiryWindow before they expire;
+// credentials without an Expiration are cached indefinitely. Safe for
+// concurrent use.
+// http://docs.aws.amazon.com/cli/latest/userguide/cli-configure-sourcing-external.html
+type ProcessProvider struct {
+	// Command is run by the system shell.
+	Command string
+
+	Timeout      time.Duration // defaults to DefaultProcessTimeout
+	ExpiryWindow time.Duration // defaults to DefaultExpiryWindow
+
+	cache CredentialsCache
+}
+
+func NewProcessProvider(command string) *ProcessProvider {
+	return &ProcessProvider{Command: command}
+}
+
+// The output of a credential_process command.
+type processOutput struct {
+	Version         int
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      *time.Time
+}
+
+func (p *ProcessProvider) Retrieve() (*Credentials, error) {
+	window := p.ExpiryWindow
+	if window == 0 {
+		window = DefaultExpiryWindow
+	}
+	return p.cache.Retrieve(window, p.fetch)
+}
+
+func (p *ProcessProvider) fetch() (*Credentials, error) {
+	if strings.TrimSpace(p.Command) == "" {
+		return nil, fmt.Errorf("aws: credential process: no command")
+	}
+	timeout := p.Timeout
+	if timeout == 0 {
+		timeout = DefaultProcessTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd.exe", "/C", p.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", p.Command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = os.Stdin // helpers may prompt for input
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, fmt.Errorf("aws: credential process: timed out after %v: %s", timeout, strings.TrimSpace(stderr.String()))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("aws: credential process: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var out processOutput
+	err = json.Unmarshal(stdout.Bytes(), &out)
+	if err != nil {
+		return nil, fmt.Errorf("aws: credential process: invalid output: %v", err)
+	}
+	if out.Version != 1 {
+		return nil, fmt.Errorf("aws: credential process: unsupported version %d", out.Version)
+	}
+	if out.AccessKeyId == "" || out.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws: credential process: output has no keys")
+	}
+	creds := &Credentials{
+		AccessKeyId:     out.AccessKeyId,
+		SecretAccessKey: out.SecretAccessKey,
+		SessionToken:    out.SessionToken,
+	}
+	if out.Expiration != nil {
+		creds.Expiration = *out.Expiration
+	}
+	return creds, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProcessProvider(t *testing.T) {
+	counter := filepath.Join(t.TempDir(), "count")
+	expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	p := NewProcessProvider(`echo x >> ` + counter + `; echo '{"Version": 1, "AccessKeyId": "AKID", "SecretAccessKey": "SECRET", "SessionToken": "TOKEN", "Expiration": "` + expires + `"}'`)
+	for i := 0; i < 2; i++ {
+		creds, err := p.Retrieve()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if creds.AccessKeyId != "AKID" || creds.SessionToken != "TOKEN" || creds.Expiration.IsZero() {
+			t.Errorf("unexpected credentials: %#v", creds)
+		}
+	}
+	if runs := strings.Count(readTestFile(t, counter), "x"); runs != 1 {
+		t.Errorf("command run %d times", runs)
+	}
+}
+
+func TestProcessProviderError(t *testing.T) {
+	_, err := NewProcessProvider(`echo "token expired" >&2; exit 1`).Retrieve()
+	if err == nil || !strings.Contains(err.Error(), "token expired") {
+		t.Errorf("expected stderr in error: %v", err)
+	}
+	_, err = NewProcessProvider(`echo '{"Version": 2}'`).Retrieve()
+	if err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("expected a version error: %v", err)
+	}
+}
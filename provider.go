@@ -0,0 +1,196 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A CredentialsProvider resolves credentials when a request is signed.
+// *Credentials is itself a provider that always returns the same keys.
+type CredentialsProvider interface {
+	Retrieve() (*Credentials, error)
+}
+
+// Retrieve returns creds, or an error if either key is missing or creds have
+// expired.
+func (creds *Credentials) Retrieve() (*Credentials, error) {
+	if creds == nil || creds.AccessKeyId == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("aws: static credentials are empty")
+	}
+	if creds.Expired() {
+		return nil, ErrCredentialsExpired
+	}
+	return creds, nil
+}
+
+// EnvProvider reads credentials from the environment variables
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and (optionally) AWS_SESSION_TOKEN.
+type EnvProvider struct{}
+
+func (EnvProvider) Retrieve() (*Credentials, error) {
+	creds := Getenv()
+	if creds.AccessKeyId == "" {
+		return nil, errors.New("aws: AWS_ACCESS_KEY_ID not set in environment")
+	}
+	if creds.SecretAccessKey == "" {
+		return nil, errors.New("aws: AWS_SECRET_ACCESS_KEY not set in environment")
+	}
+	return creds, nil
+}
+
+// SharedCredentialsProvider reads credentials from a profile in the shared
+// credentials file used by the AWS command line tools.
+type SharedCredentialsProvider struct {
+	// Defaults to $AWS_SHARED_CREDENTIALS_FILE, or ~/.aws/credentials.
+	Filename string
+	// Defaults to $AWS_PROFILE, or "default".
+	Profile string
+}
+
+func (p *SharedCredentialsProvider) filename() (string, error) {
+	if p.Filename != "" {
+		return p.Filename, nil
+	}
+	if filename := os.Getenv("AWS_SHARED_CREDENTIALS_FILE"); filename != "" {
+		return filename, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".aws", "credentials"), nil
+}
+
+func (p *SharedCredentialsProvider) profile() string {
+	if p.Profile != "" {
+		return p.Profile
+	}
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+func (p *SharedCredentialsProvider) Retrieve() (*Credentials, error) {
+	filename, err := p.filename()
+	if err != nil {
+		return nil, fmt.Errorf("aws: shared credentials: %v", err)
+	}
+	ini, err := readIniFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("aws: shared credentials: %v", err)
+	}
+	profile := p.profile()
+	section, ok := ini[profile]
+	if !ok {
+		return nil, fmt.Errorf("aws: shared credentials: %s: profile %q not found", filename, profile)
+	}
+	creds := &Credentials{
+		AccessKeyId:     section["aws_access_key_id"],
+		SecretAccessKey: section["aws_secret_access_key"],
+		SessionToken:    section["aws_session_token"],
+	}
+	if creds.AccessKeyId == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("aws: shared credentials: %s: profile %q has no keys", filename, profile)
+	}
+	return creds, nil
+}
+
+// ChainProvider returns credentials from the first of its Providers that
+// succeeds. The credentials are cached until they are about to expire, and
+// the provider which returned them is tried first when they are refreshed,
+// so the providers before it are not consulted for each request. Safe for
+// concurrent use.
+type ChainProvider struct {
+	Providers []CredentialsProvider
+
+	cache   CredentialsCache
+	mu      sync.Mutex
+	current CredentialsProvider // the provider which last succeeded
+}
+
+func NewChainProvider(providers ...CredentialsProvider) *ChainProvider {
+	return &ChainProvider{Providers: providers}
+}
+
+func (chain *ChainProvider) Retrieve() (*Credentials, error) {
+	return chain.cache.Retrieve(DefaultExpiryWindow, chain.retrieve)
+}
+
+func (chain *ChainProvider) retrieve() (*Credentials, error) {
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	if chain.current != nil {
+		creds, err := chain.current.Retrieve()
+		if err == nil {
+			return creds, nil
+		}
+		chain.current = nil
+	}
+	var errs []string
+	for _, p := range chain.Providers {
+		creds, err := p.Retrieve()
+		if err == nil {
+			chain.current = p
+			return creds, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return nil, errors.New("aws: no credentials providers in chain")
+	}
+	return nil, fmt.Errorf("aws: no valid credentials in chain: %s", strings.Join(errs, "; "))
+}
+
+// DefaultProvider looks for credentials in the environment, the shared
+// credentials file, the container credentials endpoint, and then the EC2
+// instance metadata service.
+func DefaultProvider() *ChainProvider {
+	return NewChainProvider(
+		EnvProvider{},
+		&SharedCredentialsProvider{},
+		&ContainerProvider{},
+		&Ec2RoleProvider{},
+	)
+}
+
+// Default time before expiration at which refreshing providers retrieve new
+// credentials.
+const DefaultExpiryWindow = 5 * time.Minute
+
+// CredentialsCache holds expiring credentials for refreshing providers. The
+// zero value is an empty cache. Safe for concurrent use.
+type CredentialsCache struct {
+	mu    sync.Mutex
+	creds *Credentials
+}
+
+// Retrieve returns the cached credentials unless they expire within window,
+// in which case fetch is called. Credentials which have not yet expired are
+// still returned if fetch fails.
+func (cache *CredentialsCache) Retrieve(window time.Duration, fetch func() (*Credentials, error)) (*Credentials, error) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	creds := cache.creds
+	if creds != nil && (creds.Expiration.IsZero() || time.Now().Add(window).Before(creds.Expiration)) {
+		return creds, nil
+	}
+	fresh, err := fetch()
+	if err != nil {
+		if creds != nil && !creds.Expired() {
+			return creds, nil
+		}
+		return nil, err
+	}
+	cache.creds = fresh
+	return fresh, nil
+}
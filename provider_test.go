@@ -0,0 +1,141 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSharedCredentials = `
+[default]
+aws_access_key_id = AKIDDEFAULT
+aws_secret_access_key = SECRETDEFAULT
+
+# a named profile
+[other]
+aws_access_key_id=AKIDOTHER
+aws_secret_access_key=SECRETOTHER
+
+[empty]
+`
+
+func writeTestFile(t *testing.T, name, content string) string {
+	filename := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(filename, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func readTestFile(t *testing.T, filename string) string {
+	p, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(p)
+}
+
+func TestSharedCredentialsProvider(t *testing.T) {
+	filename := writeTestFile(t, "credentials", testSharedCredentials)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filename)
+	t.Setenv("AWS_PROFILE", "")
+
+	creds, err := (&SharedCredentialsProvider{}).Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "AKIDDEFAULT" || creds.SecretAccessKey != "SECRETDEFAULT" {
+		t.Errorf("default profile: %#v", creds)
+	}
+
+	t.Setenv("AWS_PROFILE", "other")
+	creds, err = (&SharedCredentialsProvider{}).Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "AKIDOTHER" || creds.SecretAccessKey != "SECRETOTHER" {
+		t.Errorf("AWS_PROFILE=other: %#v", creds)
+	}
+
+	for _, profile := range []string{"empty", "missing"} {
+		_, err = (&SharedCredentialsProvider{Profile: profile}).Retrieve()
+		if err == nil {
+			t.Errorf("profile %q: expected an error", profile)
+		}
+	}
+}
+
+func TestChainProvider(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	missing := &SharedCredentialsProvider{Filename: filepath.Join(t.TempDir(), "missing")}
+
+	_, err := NewChainProvider(EnvProvider{}, missing).Retrieve()
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !strings.Contains(err.Error(), "AWS_ACCESS_KEY_ID") || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("error does not describe each provider: %v", err)
+	}
+
+	static := &Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}
+	creds, err := NewChainProvider(EnvProvider{}, missing, static).Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds != static {
+		t.Errorf("unexpected credentials: %#v", creds)
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDENV")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "SECRETENV")
+	creds, err = NewChainProvider(EnvProvider{}, static).Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "AKIDENV" {
+		t.Errorf("unexpected credentials: %#v", creds)
+	}
+}
+
+type countingProvider struct {
+	CredentialsProvider
+	n int
+}
+
+func (p *countingProvider) Retrieve() (*Credentials, error) {
+	p.n++
+	return p.CredentialsProvider.Retrieve()
+}
+
+func TestChainProviderCache(t *testing.T) {
+	missing := &countingProvider{CredentialsProvider: &SharedCredentialsProvider{Filename: filepath.Join(t.TempDir(), "missing")}}
+	expiring := &Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET", Expiration: time.Now().Add(time.Minute)}
+	found := &countingProvider{CredentialsProvider: expiring}
+	chain := NewChainProvider(missing, found)
+	for i := 0; i < 3; i++ {
+		if _, err := chain.Retrieve(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The credentials expire within DefaultExpiryWindow, so each call
+	// refreshes them from the provider which succeeded.
+	if missing.n != 1 || found.n != 3 {
+		t.Errorf("providers called %d and %d times", missing.n, found.n)
+	}
+
+	static := &countingProvider{CredentialsProvider: &Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}}
+	chain = NewChainProvider(static)
+	chain.Retrieve()
+	chain.Retrieve()
+	if static.n != 1 {
+		t.Errorf("static credentials retrieved %d times", static.n)
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The timestamp format of the Query protocol.
+const ISO8601 = "2006-01-02T15:04:05Z"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// EncodeQuery adds the fields of the struct v to params as AWS Query
+// protocol parameters.
+//
+// Fields are named by their "query" tag, or their Go name. Nested structs
+// are prefixed by their field name ("Message.Subject.Data"), lists are
+// numbered members ("ToAddresses.member.1") and maps are numbered entries
+// ("Attributes.entry.1.key" and "Attributes.entry.1.value"). Booleans are
+// "true" or "false", timestamps ISO8601 in UTC and []byte base64.
+//
+// Zero values, nil pointers and empty lists and maps are omitted, so a
+// pointer is needed to send a zero value. Embedded structs without a tag
+// share the prefix of their parent. Tag options follow the name:
+//
+//	Tags     []Tag             `query:"Tag,flattened"`             // Tag.1, Tag.2 (EC2 style)
+//	Attrs    map[string]string `query:"Attribute,flattened,key=Name,value=Value"`
+//	Internal string            `query:"-"`                         // never encoded
+func EncodeQuery(params url.Values, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("aws: query: cannot encode %v", rv.Type())
+	}
+	return encodeQuery(params, "", rv, queryTag{}, false)
+}
+
+type queryTag struct {
+	name      string
+	flattened bool
+	key       string
+	value     string
+}
+
+func parseQueryTag(field reflect.StructField) queryTag {
+	tag := queryTag{name: field.Name, key: "key", value: "value"}
+	opts := strings.Split(field.Tag.Get("query"), ",")
+	if opts[0] != "" {
+		tag.name = opts[0]
+	}
+	for _, opt := range opts[1:] {
+		switch {
+		case opt == "flattened":
+			tag.flattened = true
+		case strings.HasPrefix(opt, "key="):
+			tag.key = opt[len("key="):]
+		case strings.HasPrefix(opt, "value="):
+			tag.value = opt[len("value="):]
+		}
+	}
+	return tag
+}
+
+func queryName(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// Explicit values were pointed to and are encoded even when zero.
+func encodeQuery(params url.Values, name string, v reflect.Value, tag queryTag, explicit bool) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+		explicit = true
+	}
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() && !explicit {
+			return nil
+		}
+		params.Set(name, t.UTC().Format(ISO8601))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.Tag.Get("query") == "-" || (field.PkgPath != "" && !field.Anonymous) {
+				continue
+			}
+			if field.Anonymous && field.Tag.Get("query") == "" {
+				ft := field.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					if err := encodeQuery(params, name, v.Field(i), tag, false); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if field.PkgPath != "" {
+				continue
+			}
+			ftag := parseQueryTag(field)
+			if err := encodeQuery(params, queryName(name, ftag.name), v.Field(i), ftag, false); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Len() == 0 && !explicit {
+				return nil
+			}
+			p := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(p), v)
+			params.Set(name, base64.StdEncoding.EncodeToString(p))
+			return nil
+		}
+		if !tag.flattened {
+			name += ".member"
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeQuery(params, name+"."+strconv.Itoa(i+1), v.Index(i), queryTag{}, true); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("aws: query: %s: map keys must be strings", name)
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		if !tag.flattened {
+			name += ".entry"
+		}
+		for i, key := range keys {
+			entry := name + "." + strconv.Itoa(i+1)
+			params.Set(entry+"."+tag.key, key.String())
+			if err := encodeQuery(params, entry+"."+tag.value, v.MapIndex(key), queryTag{}, true); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		if v.Len() > 0 || explicit {
+			params.Set(name, v.String())
+		}
+	case reflect.Bool:
+		if v.Bool() || explicit {
+			params.Set(name, strconv.FormatBool(v.Bool()))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() != 0 || explicit {
+			params.Set(name, strconv.FormatInt(v.Int(), 10))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Uint() != 0 || explicit {
+			params.Set(name, strconv.FormatUint(v.Uint(), 10))
+		}
+	case reflect.Float32, reflect.Float64:
+		if v.Float() != 0 || explicit {
+			params.Set(name, strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()))
+		}
+	default:
+		return fmt.Errorf("aws: query: %s: cannot encode %v", name, v.Type())
+	}
+	return nil
+}
+
+// NewQueryRequest returns a Query protocol request, which POSTs params as
+// a form to the root of the region's endpoint. params should include the
+// Action and Version.
+func NewQueryRequest(region *Region, params url.Values) (*http.Request, error) {
+	uri := region.Url("", "/", nil)
+	req, err := http.NewRequest("POST", uri.String(), strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	return req, nil
+}
+
+// ResponseMetadata is embedded in the results of Query protocol calls.
+type ResponseMetadata struct {
+	RequestId string `xml:"ResponseMetadata>RequestId"`
+}
+
+// DecodeQueryResponse reads the body of a successful Query protocol
+// response into v and closes it. v is decoded from the response element,
+// so its fields are paths like "SendEmailResult>MessageId"; embed a
+// ResponseMetadata for the request id.
+func DecodeQueryResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(body, v)
+}
+
+// QueryError is the error returned by Query protocol services. It
+// implements APIError.
+type QueryError struct {
+	Service    string `xml:"-"` // prefixes the error message (e.g. "sts")
+	Type       string `xml:"Error>Type"`
+	Code       string `xml:"Error>Code"`
+	Message    string `xml:"Error>Message"`
+	RequestId  string
+	StatusCode int `xml:"-"`
+}
+
+func (err *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", err.Service, err.Code, err.Message)
+}
+
+func (err *QueryError) Is(target error) bool { return IsCode(err.Code, target) }
+func (err *QueryError) ErrorCode() string    { return err.Code }
+func (err *QueryError) ErrorMessage() string { return err.Message }
+func (err *QueryError) RequestID() string    { return err.RequestId }
+func (err *QueryError) HostID() string       { return "" }
+func (err *QueryError) HTTPStatusCode() int  { return err.StatusCode }
+func (err *QueryError) Retryable() bool      { return RetryableError(err.Code, err.StatusCode) }
+
+// The error envelope used by EC2.
+type ec2ErrorResponse struct {
+	Errors []struct {
+		Code    string
+		Message string
+	} `xml:"Errors>Error"`
+	RequestID string
+}
+
+// QueryErrorParser returns a Client.ParseError function for Query protocol
+// services. It decodes both ErrorResponse envelopes and EC2's Response
+// envelope, and returns a *QueryError for bodies it cannot decode.
+func QueryErrorParser(service string) func(resp *http.Response) error {
+	return func(resp *http.Response) error {
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		err := &QueryError{Service: service, StatusCode: resp.StatusCode}
+		if xml.Unmarshal(body, err) != nil || err.Code == "" {
+			var ec2err ec2ErrorResponse
+			if xml.Unmarshal(body, &ec2err) == nil && len(ec2err.Errors) > 0 {
+				err.Code = ec2err.Errors[0].Code
+				err.Message = ec2err.Errors[0].Message
+				err.RequestId = ec2err.RequestID
+			}
+		}
+		if err.Code == "" {
+			err.Code = StatusErrorCode(resp.StatusCode)
+			err.Message = strings.TrimSpace(string(body))
+		}
+		if err.RequestId == "" {
+			err.RequestId = resp.Header.Get("X-Amzn-Requestid")
+		}
+		return err
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testQueryTag struct {
+	Key   string
+	Value string
+}
+
+type testQueryCommon struct {
+	Marker string
+}
+
+type testQueryInput struct {
+	testQueryCommon
+	Name       string
+	Count      int
+	Zero       *int
+	Enabled    bool
+	Disabled   *bool
+	Ratio      float64
+	Created    time.Time
+	Data       []byte
+	Names      []string
+	Tags       []testQueryTag    `query:"Tag,flattened"`
+	Attributes map[string]string `query:"Attribute,key=Name,value=Value"`
+	Options    *testQueryTag
+	Skipped    string `query:"-"`
+	unexported string
+}
+
+func TestEncodeQuery(t *testing.T) {
+	zero, no := 0, false
+	input := &testQueryInput{
+		testQueryCommon: testQueryCommon{"m"},
+		Name:            "name",
+		Count:           3,
+		Zero:            &zero,
+		Enabled:         true,
+		Disabled:        &no,
+		Ratio:           0.5,
+		Created:         time.Date(2013, 7, 1, 12, 0, 0, 0, time.FixedZone("X", 3600)),
+		Data:            []byte("hi"),
+		Names:           []string{"a", "b"},
+		Tags:            []testQueryTag{{"k1", "v1"}, {"k2", ""}},
+		Attributes:      map[string]string{"y": "2", "x": "1"},
+		Options:         &testQueryTag{Key: "opt"},
+		Skipped:         "skipped",
+		unexported:      "unexported",
+	}
+	params := make(url.Values)
+	if err := EncodeQuery(params, input); err != nil {
+		t.Fatal(err)
+	}
+	expect := url.Values{
+		"Marker":                  {"m"},
+		"Name":                    {"name"},
+		"Count":                   {"3"},
+		"Zero":                    {"0"},
+		"Enabled":                 {"true"},
+		"Disabled":                {"false"},
+		"Ratio":                   {"0.5"},
+		"Created":                 {"2013-07-01T11:00:00Z"},
+		"Data":                    {"aGk="},
+		"Names.member.1":          {"a"},
+		"Names.member.2":          {"b"},
+		"Tag.1.Key":               {"k1"},
+		"Tag.1.Value":             {"v1"},
+		"Tag.2.Key":               {"k2"},
+		"Attribute.entry.1.Name":  {"x"},
+		"Attribute.entry.1.Value": {"1"},
+		"Attribute.entry.2.Name":  {"y"},
+		"Attribute.entry.2.Value": {"2"},
+		"Options.Key":             {"opt"},
+	}
+	if !reflect.DeepEqual(params, expect) {
+		t.Errorf("params\n%v\nexpected\n%v", params, expect)
+	}
+
+	if err := EncodeQuery(make(url.Values), "string"); err == nil {
+		t.Error("encoded a string")
+	}
+	if err := EncodeQuery(make(url.Values), &struct{ C chan int }{make(chan int)}); err == nil {
+		t.Error("encoded a channel")
+	}
+}
+
+func TestDecodeQueryResponse(t *testing.T) {
+	var result struct {
+		MessageId string `xml:"SendEmailResult>MessageId"`
+		ResponseMetadata
+	}
+	resp := &http.Response{Body: ioutil.NopCloser(strings.NewReader(`<SendEmailResponse>
+  <SendEmailResult><MessageId>msg-1</MessageId></SendEmailResult>
+  <ResponseMetadata><RequestId>req-1</RequestId></ResponseMetadata>
+</SendEmailResponse>`))}
+	if err := DecodeQueryResponse(resp, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.MessageId != "msg-1" || result.RequestId != "req-1" {
+		t.Errorf("result %+v", result)
+	}
+}
+
+func TestQueryErrorParser(t *testing.T) {
+	parse := QueryErrorParser("svc")
+	response := func(status int, body string) *http.Response {
+		header := http.Header{"X-Amzn-Requestid": {"header-id"}}
+		return &http.Response{StatusCode: status, Header: header, Body: ioutil.NopCloser(strings.NewReader(body))}
+	}
+	for _, test := range []struct {
+		status    int
+		body      string
+		code      string
+		message   string
+		requestID string
+	}{
+		{400, `<ErrorResponse><Error><Type>Sender</Type><Code>Throttling</Code><Message>slow down</Message></Error><RequestId>req-1</RequestId></ErrorResponse>`,
+			"Throttling", "slow down", "req-1"},
+		{400, `<Response><Errors><Error><Code>InvalidParameterValue</Code><Message>bad</Message></Error></Errors><RequestID>req-2</RequestID></Response>`,
+			"InvalidParameterValue", "bad", "req-2"},
+		{503, "unavailable", "ServiceUnavailable", "unavailable", "header-id"},
+	} {
+		err := parse(response(test.status, test.body))
+		qerr, ok := err.(*QueryError)
+		if !ok {
+			t.Errorf("error %#v", err)
+			continue
+		}
+		if qerr.Code != test.code || qerr.Message != test.message || qerr.RequestId != test.requestID || qerr.StatusCode != test.status {
+			t.Errorf("error %+v", qerr)
+		}
+		var apierr APIError
+		if !errors.As(err, &apierr) {
+			t.Errorf("%v is not an APIError", err)
+		}
+		if !strings.HasPrefix(err.Error(), "svc: "+test.code) {
+			t.Errorf("message %q", err.Error())
+		}
+	}
+	if !parse(response(400, `<ErrorResponse><Error><Code>Throttling</Code></Error></ErrorResponse>`)).(*QueryError).Retryable() {
+		t.Error("throttling is not retryable")
+	}
+	if err := parse(response(200, "")); err != nil {
+		t.Errorf("error for 200: %v", err)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Error codes which indicate the client is sending requests too quickly.
+var throttleCodes = map[string]bool{
+	"SlowDown":                               true,
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"RequestLimitExceeded":                   true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"BandwidthLimitExceeded":                 true,
+	"EC2ThrottledException":                  true,
+}
+
+// IsThrottle returns true if a request which produced resp and err was
+// rejected because too many requests were sent.
+func IsThrottle(resp *http.Response, err error) bool {
+	var coder ErrorCoder
+	if errors.As(err, &coder) && throttleCodes[coder.ErrorCode()] {
+		return true
+	}
+	return resp != nil && resp.StatusCode == http.StatusTooManyRequests
+}
+
+// RateLimiter limits the requests per second and the bytes per second
+// transferred for each key. It is a SendPhase middleware, and may be shared
+// by clients and goroutines:
+//
+//	limiter := aws.NewRateLimiter(100, 10)
+//	limiter.Key = s3.BucketKey
+//	client.Handlers.Add(aws.SendPhase, "ratelimit", limiter.Middleware)
+//
+// The request rate adapts to throttling errors, halving for each one and
+// recovering gradually as requests succeed. The state of keys which are not
+// used for IdleKeyTimeout is discarded. Fields must not be changed after the
+// limiter is used.
+type RateLimiter struct {
+	Rate  float64 // requests per second; zero for no limit
+	Burst int     // requests sent without waiting; at least one
+
+	// The lowest rate adaptation reduces Rate to. Defaults to Rate/100.
+	MinRate float64
+
+	ByteRate  float64 // request and response body bytes per second; zero for no limit
+	ByteBurst int     // defaults to ByteRate
+
+	// Returns the key limited for op (e.g. an S3 bucket). Defaults to the
+	// request's host.
+	Key func(op *Operation) string
+
+	mu      sync.Mutex
+	keys    map[string]*rateLimit
+	sweepAt int // the number of keys at which idle keys are next evicted
+}
+
+// Keys are evicted from a RateLimiter once they are unused for this long and
+// their buckets have refilled, forgetting any adaptation to throttling.
+const IdleKeyTimeout = time.Minute
+
+type rateLimit struct {
+	requests tokenBucket
+	bytes    tokenBucket
+}
+
+func (limit *rateLimit) idle(now time.Time) bool {
+	return limit.requests.idle(now) && limit.bytes.idle(now)
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{Rate: rate, Burst: burst}
+}
+
+func (l *RateLimiter) Middleware(next Handler) Handler {
+	return func(op *Operation) error {
+		key := l.key(op)
+		if err := l.Wait(op.Context, key); err != nil {
+			return err
+		}
+		if l.ByteRate > 0 && op.Request.Body != nil && op.Request.Body != http.NoBody {
+			op.Request.Body = l.limitBody(op.Context, key, op.Request.Body)
+			if getBody := op.Request.GetBody; getBody != nil {
+				op.Request.GetBody = func() (io.ReadCloser, error) {
+					body, err := getBody()
+					if err != nil {
+						return nil, err
+					}
+					return l.limitBody(op.Context, key, body), nil
+				}
+			}
+		}
+		err := next(op)
+		l.Observe(key, IsThrottle(op.Response, err))
+		if err == nil && l.ByteRate > 0 && op.Response != nil {
+			op.Response.Body = l.limitBody(op.Context, key, op.Response.Body)
+		}
+		return err
+	}
+}
+
+func (l *RateLimiter) key(op *Operation) string {
+	if l.Key != nil {
+		return l.Key(op)
+	}
+	return op.Request.URL.Host
+}
+
+func (l *RateLimiter) limit(key string) *rateLimit {
+	if l.keys == nil {
+		l.keys = make(map[string]*rateLimit)
+	}
+	limit := l.keys[key]
+	if limit == nil {
+		if len(l.keys) >= l.sweepAt {
+			l.sweep(time.Now())
+			l.sweepAt = 2 * len(l.keys)
+			if l.sweepAt < 64 {
+				l.sweepAt = 64
+			}
+		}
+		burst := l.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		byteBurst := l.byteBurst()
+		limit = &rateLimit{
+			requests: tokenBucket{max: l.Rate, rate: l.Rate, capacity: float64(burst), tokens: float64(burst)},
+			bytes:    tokenBucket{max: l.ByteRate, rate: l.ByteRate, capacity: float64(byteBurst), tokens: float64(byteBurst)},
+		}
+		l.keys[key] = limit
+	}
+	return limit
+}
+
+// Evicts idle keys. Sweeps are spaced by the growth of the map so their cost
+// is amortized over the keys added.
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, limit := range l.keys {
+		if limit.idle(now) {
+			delete(l.keys, key)
+		}
+	}
+}
+
+// Wait blocks until a request for key may be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context, key string) error {
+	if l.Rate <= 0 {
+		return nil
+	}
+	return l.wait(ctx, key, 1, func(limit *rateLimit) *tokenBucket { return &limit.requests })
+}
+
+// WaitBytes blocks until n bytes for key may be transferred or ctx is done.
+func (l *RateLimiter) WaitBytes(ctx context.Context, key string, n int) error {
+	if l.ByteRate <= 0 || n <= 0 {
+		return nil
+	}
+	return l.wait(ctx, key, float64(n), func(limit *rateLimit) *tokenBucket { return &limit.bytes })
+}
+
+func (l *RateLimiter) wait(ctx context.Context, key string, n float64, bucket func(*rateLimit) *tokenBucket) error {
+	l.mu.Lock()
+	b := bucket(l.limit(key))
+	delay := b.take(time.Now(), n)
+	l.mu.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		b.tokens += n
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Observe adapts the request rate for key to the result of a request.
+func (l *RateLimiter) Observe(key string, throttled bool) {
+	if l.Rate <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b := &l.limit(key).requests
+	if throttled {
+		min := l.MinRate
+		if min <= 0 {
+			min = l.Rate / 100
+		}
+		b.setRate(time.Now(), b.rate/2, min)
+	} else if b.rate < b.max {
+		b.setRate(time.Now(), b.rate+b.max/20, 0)
+	}
+}
+
+// CurrentRate returns the adapted request rate for key.
+func (l *RateLimiter) CurrentRate(key string) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit(key).requests.rate
+}
+
+func (l *RateLimiter) limitBody(ctx context.Context, key string, body io.ReadCloser) io.ReadCloser {
+	return &limitedBody{body, func(n int) error { return l.WaitBytes(ctx, key, n) }, l.byteBurst()}
+}
+
+func (l *RateLimiter) byteBurst() int {
+	if l.ByteBurst > 0 {
+		return l.ByteBurst
+	}
+	if l.ByteRate >= 1 {
+		return int(l.ByteRate)
+	}
+	return 1
+}
+
+// Waits for the bytes of each read after it returns, so a read is never
+// larger than the bucket's capacity.
+type limitedBody struct {
+	io.ReadCloser
+	wait func(n int) error
+	max  int
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if len(p) > body.max {
+		p = p[:body.max]
+	}
+	n, err := body.ReadCloser.Read(p)
+	if werr := body.wait(n); werr != nil && err == nil {
+		err = werr
+	}
+	return n, err
+}
+
+// Tokens may go negative; the time taken to refill them is the delay before
+// the reservation may be used.
+type tokenBucket struct {
+	max      float64 // configured rate
+	rate     float64 // tokens per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+}
+
+// A bucket is idle if it is unused for IdleKeyTimeout and has refilled.
+func (b *tokenBucket) idle(now time.Time) bool {
+	if b.last.IsZero() {
+		return true
+	}
+	if now.Sub(b.last) < IdleKeyTimeout {
+		return false
+	}
+	return b.rate <= 0 || b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.capacity
+}
+
+func (b *tokenBucket) take(now time.Time, n float64) time.Duration {
+	b.refill(now)
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) setRate(now time.Time, rate, min float64) {
+	b.refill(now)
+	if rate < min {
+		rate = min
+	}
+	if rate > b.max {
+		rate = b.max
+	}
+	b.rate = rate
+}
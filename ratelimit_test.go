@@ -0,0 +1,114 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	throttle := false
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if throttle {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, strings.Repeat("x", 3000))
+	})
+	client.Retryer = nil
+	limiter := NewRateLimiter(50, 1)
+	client.Handlers.Add(SendPhase, "ratelimit", limiter.Middleware)
+	key := client.Region.Endpoint
+
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if err := client.Run(testOperation(client)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("6 requests at 50/s took %v", elapsed)
+	}
+
+	throttle = true
+	client.Run(testOperation(client))
+	if rate := limiter.CurrentRate(key); rate != 25 {
+		t.Errorf("throttled rate %v", rate)
+	}
+	limiter.Observe(key, false)
+	if rate := limiter.CurrentRate(key); rate != 27.5 {
+		t.Errorf("recovered rate %v", rate)
+	}
+	for i := 0; i < 10; i++ {
+		limiter.Observe(key, true)
+	}
+	if rate := limiter.CurrentRate(key); rate != 0.5 {
+		t.Errorf("minimum rate %v", rate)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	limiter.Wait(ctx, key)
+	if err := limiter.Wait(ctx, key); err != context.DeadlineExceeded {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestRateLimiterEviction(t *testing.T) {
+	limiter := NewRateLimiter(1000, 1)
+	for i := 0; i < 100; i++ {
+		limiter.Wait(context.Background(), fmt.Sprint("bucket", i))
+	}
+	limiter.Observe("bucket0", true)
+	if n := len(limiter.keys); n != 100 {
+		t.Fatalf("%d keys", n)
+	}
+	limiter.mu.Lock()
+	limiter.sweep(time.Now())
+	if n := len(limiter.keys); n != 100 {
+		t.Errorf("%d keys after evicting recently used keys", n)
+	}
+	limiter.sweep(time.Now().Add(IdleKeyTimeout))
+	if n := len(limiter.keys); n != 0 {
+		t.Errorf("%d keys after evicting idle keys", n)
+	}
+	limiter.mu.Unlock()
+
+	for i := 0; i < 1000; i++ {
+		limiter.Wait(context.Background(), fmt.Sprint("key", i))
+	}
+	if limiter.sweepAt <= len(limiter.keys) {
+		t.Errorf("next sweep at %d of %d keys", limiter.sweepAt, len(limiter.keys))
+	}
+}
+
+func TestRateLimiterBytes(t *testing.T) {
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	})
+	limiter := &RateLimiter{ByteRate: 10000, ByteBurst: 1000}
+	client.Handlers.Add(SendPhase, "ratelimit", limiter.Middleware)
+
+	op := testOperation(client)
+	op.Build = func(op *Operation) (err error) {
+		body := strings.Repeat("x", 1500)
+		op.Request, err = http.NewRequest("PUT", client.Region.Url("", "/", nil).String(), strings.NewReader(body))
+		return err
+	}
+	start := time.Now()
+	if err := client.Run(op); err != nil {
+		t.Fatal(err)
+	}
+	// 3000 bytes sent and received, less the burst, at 10000 bytes/s.
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("3000 bytes at 10000/s took %v", elapsed)
+	}
+	if len(op.Output.(string)) != 1500 {
+		t.Errorf("response body %d bytes", len(op.Output.(string)))
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Defaults for NewRetryer.
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseDelay   = 100 * time.Millisecond
+	DefaultMaxDelay    = 20 * time.Second
+)
+
+// ErrorCoder is implemented by service errors which carry an AWS error code.
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// Error codes which indicate throttling or a transient service failure.
+var retryableCodes = map[string]bool{
+	"InternalError":                          true,
+	"RequestTimeout":                         true,
+	"RequestTimeoutException":                true,
+	"ServiceUnavailable":                     true,
+	"SlowDown":                               true,
+	"Throttling":                             true,
+	"ThrottlingException":                    true,
+	"ThrottledException":                     true,
+	"RequestThrottled":                       true,
+	"RequestThrottledException":              true,
+	"RequestLimitExceeded":                   true,
+	"TooManyRequestsException":               true,
+	"ProvisionedThroughputExceededException": true,
+	"BandwidthLimitExceeded":                 true,
+	"TransactionInProgressException":         true,
+	"PriorRequestNotComplete":                true,
+	"EC2ThrottledException":                  true,
+	CRC32CheckFailed:                         true,
+}
+
+// Retryable returns true if a request which produced resp and err may
+// succeed if sent again.
+func Retryable(resp *http.Response, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if err != nil {
+		var apierr APIError
+		if errors.As(err, &apierr) && apierr.Retryable() {
+			return true
+		}
+		var coder ErrorCoder
+		if errors.As(err, &coder) && retryableCodes[coder.ErrorCode()] {
+			return true
+		}
+		if resp == nil {
+			return retryableNetError(err)
+		}
+	}
+	return resp != nil && retryableStatus(resp.StatusCode)
+}
+
+func retryableNetError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var neterr net.Error
+	if errors.As(err, &neterr) && neterr.Timeout() {
+		return true
+	}
+	var operr *net.OpError
+	return errors.As(err, &operr)
+}
+
+func isTimeout(err error) bool {
+	var neterr net.Error
+	return errors.As(err, &neterr) && neterr.Timeout()
+}
+
+// Retryer retries requests which fail with retryable errors, waiting a
+// random delay up to an exponentially growing limit between attempts (full
+// jitter). Safe for concurrent use.
+type Retryer struct {
+	MaxAttempts int // one means no retries
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	// Limits retries when most requests are failing. May be nil.
+	Budget *RetryBudget
+
+	// Decides which failures are retried. Defaults to Retryable.
+	ShouldRetry func(resp *http.Response, err error) bool
+
+	sleep func(time.Duration)
+}
+
+func NewRetryer() *Retryer {
+	return &Retryer{
+		MaxAttempts: DefaultMaxAttempts,
+		BaseDelay:   DefaultBaseDelay,
+		MaxDelay:    DefaultMaxDelay,
+		Budget:      NewRetryBudget(),
+	}
+}
+
+// Do calls attempt until it succeeds, fails with an error which is not
+// retryable, MaxAttempts is reached, or ctx is done. Each call to attempt
+// must build, sign and send a new request. The bodies of failed responses
+// are closed before retrying.
+func (r *Retryer) Do(ctx context.Context, attempt func() (*http.Response, error)) (*http.Response, error) {
+	if r == nil {
+		return attempt()
+	}
+	shouldRetry := r.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = Retryable
+	}
+	for n := 1; ; n++ {
+		resp, err := attempt()
+		if err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			if r.Budget != nil {
+				r.Budget.release(n > 1)
+			}
+			return resp, nil
+		}
+		if n >= r.MaxAttempts || ctx.Err() != nil || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		cost := retryCost
+		if isTimeout(err) {
+			cost = retryTimeoutCost
+		}
+		if r.Budget != nil && !r.Budget.acquire(cost) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if werr := r.wait(ctx, r.Delay(n, resp)); werr != nil {
+			return nil, werr
+		}
+	}
+}
+
+// Delay returns the time to wait after the given attempt (counting from
+// one). A Retry-After header in resp is honored, up to MaxDelay.
+func (r *Retryer) Delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			if r.MaxDelay > 0 && after > r.MaxDelay {
+				return r.MaxDelay
+			}
+			return after
+		}
+	}
+	limit := r.BaseDelay << uint(attempt-1)
+	if limit <= 0 || (r.MaxDelay > 0 && limit > r.MaxDelay) {
+		limit = r.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+func (r *Retryer) wait(ctx context.Context, d time.Duration) error {
+	if r.sleep != nil {
+		r.sleep(d)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Parses delay-seconds or an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	d := time.Until(t)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
+
+// Costs of retries drawn from a RetryBudget.
+const (
+	DefaultRetryBudget = 500
+	retryCost          = 5
+	retryTimeoutCost   = 10
+)
+
+// RetryBudget is a token bucket shared by the requests of a client. Each
+// retry costs tokens and each successful request returns some, so retries
+// stop when most requests fail. Safe for concurrent use.
+type RetryBudget struct {
+	mu       sync.Mutex
+	tokens   int
+	capacity int
+}
+
+func NewRetryBudget() *RetryBudget {
+	return &RetryBudget{tokens: DefaultRetryBudget, capacity: DefaultRetryBudget}
+}
+
+func (budget *RetryBudget) acquire(cost int) bool {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	if budget.tokens < cost {
+		return false
+	}
+	budget.tokens -= cost
+	return true
+}
+
+// Called on success; a request which needed retries refunds the cost of one.
+func (budget *RetryBudget) release(retried bool) {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+	if retried {
+		budget.tokens += retryCost
+	} else {
+		budget.tokens++
+	}
+	if budget.tokens > budget.capacity {
+		budget.tokens = budget.capacity
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+type testCodeError string
+
+func (err testCodeError) Error() string     { return string(err) }
+func (err testCodeError) ErrorCode() string { return string(err) }
+
+func testResponse(status int, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	for _, test := range []struct {
+		resp      *http.Response
+		err       error
+		retryable bool
+	}{
+		{testResponse(503, nil), testCodeError("SlowDown"), true},
+		{testResponse(400, nil), testCodeError("RequestTimeout"), true},
+		{testResponse(500, nil), nil, true},
+		{testResponse(403, nil), testCodeError("AccessDenied"), false},
+		{testResponse(404, nil), nil, false},
+		{nil, syscall.ECONNRESET, true},
+		{nil, errors.New("aws: static credentials are empty"), false},
+	} {
+		if Retryable(test.resp, test.err) != test.retryable {
+			t.Errorf("%v %v: expected retryable=%v", test.resp, test.err, test.retryable)
+		}
+	}
+}
+
+func TestRetryerDo(t *testing.T) {
+	var delays []time.Duration
+	r := NewRetryer()
+	r.MaxAttempts = 4
+	r.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	attempts := 0
+	resp, err := r.Do(context.Background(), func() (*http.Response, error) {
+		attempts++
+		switch attempts {
+		case 1:
+			return testResponse(503, http.Header{"Retry-After": {"2"}}), testCodeError("SlowDown")
+		case 2:
+			return nil, syscall.ECONNRESET
+		}
+		return testResponse(200, nil), nil
+	})
+	if err != nil || resp.StatusCode != 200 {
+		t.Fatalf("unexpected result: %v %v", resp, err)
+	}
+	if attempts != 3 {
+		t.Errorf("%d attempts", attempts)
+	}
+	if len(delays) != 2 || delays[0] != 2*time.Second || delays[1] > 2*r.BaseDelay {
+		t.Errorf("unexpected delays: %v", delays)
+	}
+
+	attempts = 0
+	_, err = r.Do(context.Background(), func() (*http.Response, error) {
+		attempts++
+		return testResponse(403, nil), testCodeError("AccessDenied")
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("non-retryable error retried %d times: %v", attempts, err)
+	}
+
+	attempts = 0
+	_, err = r.Do(context.Background(), func() (*http.Response, error) {
+		attempts++
+		return testResponse(500, nil), testCodeError("InternalError")
+	})
+	if err == nil || attempts != r.MaxAttempts {
+		t.Errorf("expected %d attempts, got %d: %v", r.MaxAttempts, attempts, err)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	r := NewRetryer()
+	r.MaxAttempts = 1000
+	r.sleep = func(time.Duration) {}
+	attempts := 0
+	r.Do(context.Background(), func() (*http.Response, error) {
+		attempts++
+		return testResponse(503, nil), testCodeError("ServiceUnavailable")
+	})
+	if expect := DefaultRetryBudget/retryCost + 1; attempts != expect {
+		t.Errorf("expected budget to allow %d attempts, got %d", expect, attempts)
+	}
+}
+
+func TestRetryerCancel(t *testing.T) {
+	r := NewRetryer()
+	r.BaseDelay = time.Hour
+	r.MaxDelay = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := r.Do(ctx, func() (*http.Response, error) {
+		attempts++
+		return testResponse(503, http.Header{"Retry-After": {"3600"}}), testCodeError("SlowDown")
+	})
+	if err != context.Canceled || attempts != 1 {
+		t.Errorf("expected cancellation after one attempt, got %d: %v", attempts, err)
+	}
+}
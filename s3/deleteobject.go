@@ -1,6 +1,7 @@
 package s3
 
 import (
+	"context"
 	"io"
 	"net/http"
 
@@ -38,7 +39,11 @@ func (client *Client) DeleteObject(bucket, key string) *DeleteObject {
 }
 
 func (request *DeleteObject) Exec() (*DeleteObjectResponse, error) {
-	resp, err := request.client.Do(request)
+	return request.ExecContext(context.Background())
+}
+
+func (request *DeleteObject) ExecContext(ctx context.Context) (*DeleteObjectResponse, error) {
+	resp, err := request.client.DoContext(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -51,12 +56,12 @@ func (request *DeleteObject) Exec() (*DeleteObjectResponse, error) {
 }
 
 func (request *DeleteObject) Request(region *aws.Region) (*http.Request, error) {
-	uri := region.Url("", request.base.Path, nil)
+	uri := request.client.url(region, request.base.Path)
 	req, err := http.NewRequest(request.base.Method, uri.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = request.base.Header
+	req.Header = request.base.Header.Clone()
 	return req, nil
 }
 
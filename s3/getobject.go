@@ -1,6 +1,7 @@
 package s3
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/url"
@@ -41,7 +42,11 @@ func (client *Client) GetObject(bucket, key string) *GetObject {
 }
 
 func (request *GetObject) Exec() (*GetObjectResponse, error) {
-	resp, err := request.client.Do(request)
+	return request.ExecContext(context.Background())
+}
+
+func (request *GetObject) ExecContext(ctx context.Context) (*GetObjectResponse, error) {
+	resp, err := request.client.DoContext(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -54,12 +59,12 @@ func (request *GetObject) Exec() (*GetObjectResponse, error) {
 }
 
 func (request *GetObject) Request(region *aws.Region) (*http.Request, error) {
-	uri := region.Url("", request.base.Path, nil)
+	uri := request.client.url(region, request.base.Path)
 	req, err := http.NewRequest(request.base.Method, uri.String(), nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = request.base.Header
+	req.Header = request.base.Header.Clone()
 	return req, nil
 }
 
@@ -2,6 +2,7 @@ package s3
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
@@ -16,7 +17,7 @@ import (
 type PutObject struct {
 	base   baseRequest
 	bucket string
-	body   io.Reader
+	body   []byte // nil for no body
 	client *Client
 }
 type PutObjectResponse struct {
@@ -44,7 +45,11 @@ func (client *Client) PutObject(bucket, key string) *PutObject {
 	}
 }
 func (request *PutObject) Exec() (*PutObjectResponse, error) {
-	resp, err := request.client.Do(request)
+	return request.ExecContext(context.Background())
+}
+
+func (request *PutObject) ExecContext(ctx context.Context) (*PutObjectResponse, error) {
+	resp, err := request.client.DoContext(ctx, request)
 	if err != nil {
 		return nil, err
 	}
@@ -57,17 +62,23 @@ func (request *PutObject) Exec() (*PutObjectResponse, error) {
 }
 
 func (request *PutObject) Request(region *aws.Region) (*http.Request, error) {
-	uri := region.Url("", request.base.Path, nil)
-	req, err := http.NewRequest(request.base.Method, uri.String(), request.body)
+	uri := request.client.url(region, request.base.Path)
+	var body io.Reader
+	if request.body != nil {
+		// each attempt gets its own reader, as the transport may still be
+		// reading the body of a failed attempt
+		body = bytes.NewReader(request.body)
+	}
+	req, err := http.NewRequest(request.base.Method, uri.String(), body)
 	if err != nil {
 		return nil, err
 	}
-	req.Header = request.base.Header
+	req.Header = request.base.Header.Clone()
 	return req, nil
 }
 
 func (request *PutObject) Content(data []byte) *PutObject {
-	request.body = bytes.NewBuffer(data)
+	request.body = data
 	request.base.Header.Add("Content-Length", strconv.Itoa(len(data)))
 	h := md5.New()
 	h.Write(data)
@@ -113,7 +124,7 @@ func (request *PutObject) CacheControl(control string) *PutObject {
 	return request
 }
 func (request *PutObject) Expires(lifetime time.Duration) *PutObject {
-	ms := int64(lifetime)/1e3
+	ms := int64(lifetime) / 1e3
 	request.base.Header.Add("Expires", strconv.FormatInt(ms, 10))
 	return request
 }
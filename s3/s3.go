@@ -18,53 +18,179 @@ package s3
  */
 
 import (
-	"crypto/hmac"
-	"crypto/sha1"
-	"encoding/base64"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"sort"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
-	"unicode"
 
 	"github.com/bmatsuo/go-aws"
 )
 
 var (
-	USStandard   = &aws.Region{"https", "s3.amazonaws.com"}
-	USWest1      = &aws.Region{"https", "s3-us-west-1.amazonaws.com"}
-	USWest2      = &aws.Region{"https", "s3-us-west-2.amazonaws.com"}
-	EUWest1      = &aws.Region{"https", "s3-eu-west-1.amazonaws.com"}
-	APSouthEast1 = &aws.Region{"https", "s3-as-southeast-1.amazonaws.com"}
-	APSouthEast2 = &aws.Region{"https", "s3-as-southeast-2.amazonaws.com"}
-	APNorthEast2 = &aws.Region{"https", "s3-as-northeast-2.amazonaws.com"}
-	SAEast1      = &aws.Region{"https", "s3-sa-east-1.amazonaws.com"}
+	USStandard   = mustRegion(aws.USEast1)
+	USEast2      = mustRegion(aws.USEast2)
+	USWest1      = mustRegion(aws.USWest1)
+	USWest2      = mustRegion(aws.USWest2)
+	EUWest1      = mustRegion(aws.EUWest1)
+	EUCentral1   = mustRegion(aws.EUCentral1)
+	APSouthEast1 = mustRegion(aws.APSoutheast1)
+	APSouthEast2 = mustRegion(aws.APSoutheast2)
+	APNorthEast1 = mustRegion(aws.APNortheast1)
+	APNorthEast2 = mustRegion(aws.APNortheast2)
+	SAEast1      = mustRegion(aws.SAEast1)
 )
 
+// Region returns the S3 endpoint for the named region (e.g. "us-west-2").
+func Region(name string) (*aws.Region, error) {
+	return aws.ResolveEndpoint("s3", name)
+}
+
+func mustRegion(name string) *aws.Region {
+	region, err := Region(name)
+	if err != nil {
+		panic(err)
+	}
+	return region
+}
+
+// BucketKey returns the bucket of op's request, to limit requests per
+// bucket with an aws.RateLimiter.
+func BucketKey(op *aws.Operation) string {
+	return PrefixKey(0)(op)
+}
+
+// PrefixKey returns a key function for an aws.RateLimiter which limits
+// requests per bucket and up to depth "/" separated directories of the
+// object key. S3 throttles requests per prefix.
+func PrefixKey(depth int) func(op *aws.Operation) string {
+	return func(op *aws.Operation) string {
+		host := op.Request.URL.Host
+		bucket, key := splitPath(op.Request.URL.Path)
+		if b, _ := op.Attributes[AttrBucket].(string); b != "" && strings.HasPrefix(host, b+".") {
+			// a virtual-hosted request
+			host, bucket, key = strings.TrimPrefix(host, b+"."), b, strings.TrimPrefix(op.Request.URL.Path, "/")
+		}
+		parts := strings.Split(key, "/")
+		dirs := len(parts) - 1 // the object name is not a directory
+		if dirs > depth {
+			dirs = depth
+		}
+		return host + "/" + strings.Join(append([]string{bucket}, parts[:dirs]...), "/")
+	}
+}
+
+// Client runs each API call through the phases of the embedded
+// *aws.Client, whose Handlers may be used to customize requests.
 type Client struct {
-	*aws.Credentials
-	*aws.Region
-	client *http.Client
+	*aws.Client
+
+	// How buckets are addressed, as by the s3.addressing_style setting of a
+	// config profile. "virtual" puts the bucket in the host name
+	// (bucket.s3.amazonaws.com), and "auto" does so for buckets whose names
+	// are valid host names. Otherwise the bucket is the first element of the
+	// path (s3.amazonaws.com/bucket).
+	AddressingStyle string
 }
 
-func NewClient(creds *aws.Credentials, region *aws.Region) *Client {
-	return &Client{
-		Credentials: creds,
-		Region:      region,
-		client:      &http.Client{},
+// Credentials are retrieved from creds each time a request is signed. A
+// *aws.Credentials may be given for static keys.
+func NewClient(creds aws.CredentialsProvider, region *aws.Region) *Client {
+	client := aws.NewClient("s3", creds, region)
+	client.Signer.DisableURIPathEscaping = true
+	client.Signer.ContentSha256Header = true
+	client.ParseError = parseError
+	return &Client{Client: client}
+}
+
+// NewClientConfig returns a client for the region, endpoint, credentials,
+// retries and addressing style of config.
+//
+//	config, err := aws.LoadConfig("")
+//	...
+//	client, err := s3.NewClientConfig(config)
+func NewClientConfig(config *aws.Config) (*Client, error) {
+	region, err := config.Endpoint("s3")
+	if err != nil {
+		return nil, err
 	}
+	client := NewClient(config.Credentials, region)
+	config.Configure(client.Client)
+	client.AddressingStyle = config.S3.AddressingStyle
+	return client, nil
+}
+
+// Returns the URL for a path-style request path in region.
+func (client *Client) url(region *aws.Region, path string) *url.URL {
+	bucket, key := splitPath(path)
+	if bucket != "" && client.virtualHosted(region, bucket) {
+		return region.Url(bucket, "/"+key, nil)
+	}
+	return region.Url("", path, nil)
+}
+
+var dnsBucket = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+func (client *Client) virtualHosted(region *aws.Region, bucket string) bool {
+	switch client.AddressingStyle {
+	case "virtual":
+		return true
+	case "auto":
+		// Certificates do not cover the subdomains of dotted bucket names.
+		return dnsBucket.MatchString(bucket) && !strings.Contains(bucket, "..") &&
+			!(region.Protocol == "https" && strings.Contains(bucket, "."))
+	}
+	return false
 }
 
 type Request interface {
 	Request(*aws.Region) (*http.Request, error)
 }
 
+// Error codes returned by S3. Errors match their code with errors.Is.
+// http://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html
+const (
+	AccessDenied            aws.Code = "AccessDenied"
+	BucketAlreadyExists     aws.Code = "BucketAlreadyExists"
+	BucketAlreadyOwnedByYou aws.Code = "BucketAlreadyOwnedByYou"
+	BucketNotEmpty          aws.Code = "BucketNotEmpty"
+	EntityTooLarge          aws.Code = "EntityTooLarge"
+	EntityTooSmall          aws.Code = "EntityTooSmall"
+	InternalError           aws.Code = "InternalError"
+	InvalidAccessKeyId      aws.Code = "InvalidAccessKeyId"
+	InvalidArgument         aws.Code = "InvalidArgument"
+	InvalidBucketName       aws.Code = "InvalidBucketName"
+	InvalidObjectState      aws.Code = "InvalidObjectState"
+	InvalidRange            aws.Code = "InvalidRange"
+	InvalidRequest          aws.Code = "InvalidRequest"
+	MalformedXML            aws.Code = "MalformedXML"
+	MethodNotAllowed        aws.Code = "MethodNotAllowed"
+	MissingContentLength    aws.Code = "MissingContentLength"
+	NoSuchBucket            aws.Code = "NoSuchBucket"
+	NoSuchKey               aws.Code = "NoSuchKey"
+	NoSuchUpload            aws.Code = "NoSuchUpload"
+	PermanentRedirect       aws.Code = "PermanentRedirect"
+	PreconditionFailed      aws.Code = "PreconditionFailed"
+	RequestTimeTooSkewed    aws.Code = "RequestTimeTooSkewed"
+	SignatureDoesNotMatch   aws.Code = "SignatureDoesNotMatch"
+	SlowDown                aws.Code = "SlowDown"
+	TemporaryRedirect       aws.Code = "TemporaryRedirect"
+
+	// Responses without a body (e.g. to HEAD requests) have a code derived
+	// from their status.
+	NotModified aws.Code = "NotModified"
+	NotFound    aws.Code = "NotFound"
+	Forbidden   aws.Code = "Forbidden"
+)
+
+// Error is returned for responses with a status other than 2xx. It
+// implements aws.APIError.
 type Error struct {
 	Code              string
 	Message           string
@@ -72,158 +198,165 @@ type Error struct {
 	Header            string
 	StringToSignBytes string
 	SignatureProvided string
+	StatusCode        int `xml:"-"`
 }
 
 func (err *Error) StringToSign() string {
 	var p []byte
 	for _, cstr := range strings.Split(err.StringToSignBytes, " ") {
-		c, _ := strconv.ParseInt(cstr, 16, 8)
+		c, _ := strconv.ParseUint(cstr, 16, 8)
 		p = append(p, byte(c))
 	}
 	return string(p)
 }
 
 func (err *Error) Error() string {
-	return fmt.Sprintf("%#v", err)
+	msg := "s3: " + err.Code
+	if err.Message != "" {
+		msg += ": " + err.Message
+	}
+	return fmt.Sprintf("%s (status %d, request id %q)", msg, err.StatusCode, err.RequestId)
 }
 
+func (err *Error) Is(target error) bool { return aws.IsCode(err.Code, target) }
+func (err *Error) ErrorCode() string    { return err.Code }
+func (err *Error) ErrorMessage() string { return err.Message }
+func (err *Error) RequestID() string    { return err.RequestId }
+func (err *Error) HostID() string       { return err.HostId }
+func (err *Error) HTTPStatusCode() int  { return err.StatusCode }
+func (err *Error) Retryable() bool      { return aws.RetryableError(err.Code, err.StatusCode) }
+
 // Creates a signed request from req
 func (client *Client) Request(req Request) (*http.Request, error) {
 	hreq, err := req.Request(client.Region)
 	if err != nil {
 		return nil, err
 	}
-	client.Sign(hreq)
+	err = client.Sign(hreq)
+	if err != nil {
+		return nil, err
+	}
 	return hreq, nil
 }
 
 func (client *Client) Do(req Request) (*http.Response, error) {
-	hreq, err := client.Request(req)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := client.client.Do(hreq)
-	if err != nil {
-		return nil, err
-	}
-	if resp.Header.Get("Content-Type") == "application/xml" && resp.StatusCode >= 300 && resp.StatusCode < 600 {
-		var err Error
-		body, _ := ioutil.ReadAll(resp.Body)
-		defer resp.Body.Close()
-		xml.Unmarshal(body, &err)
-		return resp, &err
-	}
-	return resp, nil
-}
-
-// REST authentication
-func (client *Client) Sign(req *http.Request) {
-	now := time.Now().UTC().Format(time.RFC1123)
-	req.Header.Set("Date", now)
-	req.Header.Set("x-amz-date", now)
-	tosign := req.Method
-	tosign += "\n"
-	tosign += req.Header.Get("Content-MD5")
-	tosign += "\n"
-	tosign += req.Header.Get("Content-Type")
-	tosign += "\n"
-	//tosign += now
-	tosign += "\n"
-	headers := make(normalizedHeaders, 0, len(req.Header))
-	for name, value := range req.Header {
-		headers = append(headers, newNormalizedHeader(name, value))
-	}
-	sort.Sort(headers)
-	for _, h := range headers {
-		if !h.IsAWS() {
-			continue
-		}
-		tosign += h.Normal
-		tosign += ":"
-		tosign += h.Value
-		tosign += "\n"
-	}
-
-	resource := req.URL.Path
-	if req.URL.RawQuery != "" {
-		// ?acl and ?torrent (http://bit.ly/pO46SK)
-		resource += "?"
-		resource += req.URL.RawQuery
-	}
-	tosign += resource
-
-	auth := "AWS"
-	auth += " "
-	auth += client.Credentials.AccessKeyId
-	auth += ":"
-	auth += client.signature(tosign)
-	req.Header.Set("Authorization", auth)
-}
-
-// Query string authentication
-func (client *Client) SignUrl(req *url.URL, lifetime int64) {
-	tosign := "GET"
-	tosign += "\n"
-	tosign += "\n" // no content-type/md5 for GET requests
-	tosign += "\n"
-	expires := strconv.FormatInt(time.Now().Unix()+lifetime, 10)
-	tosign += expires
-	tosign += "\n"
-	tosign += req.Path
-
-	query := "AWSAccessKeyId="
-	query += client.Credentials.AccessKeyId
-	query += "&Expires="
-	query += expires
-	query += "&Signature="
-	query += string(client.signature(tosign))
-
-	req.RawQuery = query
-}
-func (client *Client) signature(tosign string) string {
-	hash := hmac.New(sha1.New, []byte(client.Credentials.SecretAccessKey))
-	hash.Write([]byte(tosign))
-	sum := hash.Sum(make([]byte, 0, 50))
-	signature := make([]byte, base64.URLEncoding.EncodedLen(len(sum)))
-	base64.StdEncoding.Encode(signature, sum)
-	return string(signature)
+	return client.DoContext(context.Background(), req)
 }
 
-type baseRequest struct {
-	Method string
-	Path   string
-	Query  url.Values
-	Header http.Header
-	Body   io.ReadCloser
+// DoContext sends req, retrying failures according to client.Retryer. The
+// request is rebuilt and signed again for each attempt. Canceling ctx aborts
+// the request, any retries, and reads of the response body.
+func (client *Client) DoContext(ctx context.Context, req Request) (*http.Response, error) {
+	op := &aws.Operation{
+		Context: ctx,
+		Name:    aws.OperationName(req),
+		Build: func(op *aws.Operation) (err error) {
+			op.Request, err = req.Request(client.Region)
+			if err != nil {
+				return err
+			}
+			bucket, key := client.splitURL(op.Request.URL)
+			op.Attributes = map[string]interface{}{AttrBucket: bucket, AttrKey: key}
+			return nil
+		},
+	}
+	err := client.Run(op)
+	return op.Response, err
 }
 
-type normalizedHeaders []*normalizedHeader
-type normalizedHeader struct {
-	Normal string
-	Header string // original header
-	Value  string // trimmed of concatenated
+// Trace span attributes.
+const (
+	AttrBucket = "aws.s3.bucket"
+	AttrKey    = "aws.s3.key"
+)
+
+// Returns the bucket and object key of a request URL in either addressing
+// style.
+func (client *Client) splitURL(u *url.URL) (bucket, key string) {
+	if suffix := "." + client.Region.Endpoint; strings.HasSuffix(u.Host, suffix) {
+		return strings.TrimSuffix(u.Host, suffix), strings.TrimPrefix(u.Path, "/")
+	}
+	return splitPath(u.Path)
 }
 
-func newNormalizedHeader(header string, value []string) *normalizedHeader {
-	h := &normalizedHeader{
-		Normal: strings.ToLower(string(header)),
-		Header: header,
+// Returns the bucket and object key of a path-style request path.
+func splitPath(path string) (bucket, key string) {
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[:i], path[i+1:]
 	}
+	return path, ""
+}
 
-	values := make([]string, 0, len(value))
-	for i := range value {
-		normal := strings.TrimFunc(value[i], unicode.IsSpace)
-		values = append(values, normal)
+// Returns an *Error for responses with a status other than 2xx, which need
+// not have a body.
+func parseError(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	err := &Error{StatusCode: resp.StatusCode}
+	if aws.IsXML(resp.Header.Get("Content-Type")) {
+		xml.Unmarshal(body, err)
 	}
-	h.Value = strings.Join(values, ",")
+	if err.Code == "" {
+		err.Code = aws.StatusErrorCode(resp.StatusCode)
+		err.Message = strings.TrimSpace(string(body))
+	}
+	if err.RequestId == "" {
+		err.RequestId = resp.Header.Get("X-Amz-Request-Id")
+	}
+	if err.HostId == "" {
+		err.HostId = resp.Header.Get("X-Amz-Id-2")
+	}
+	return err
+}
 
-	return h
+// Presign returns a URL for req which is valid for the expires duration
+// (capped at aws.MaxPresignExpiry), and the headers which must be sent with
+// it. The payload is not signed, so a presigned PutObject accepts any body.
+func (client *Client) Presign(req Request, expires time.Duration) (*url.URL, http.Header, error) {
+	hreq, err := req.Request(client.Region)
+	if err != nil {
+		return nil, nil, err
+	}
+	creds, err := client.Credentials.Retrieve()
+	if err != nil {
+		return nil, nil, err
+	}
+	signer := client.NewSigner()
+	signer.DisablePayloadSigning = true
+	return signer.Presign(creds, hreq, expires)
 }
 
-func (h normalizedHeader) IsAWS() bool {
-	return strings.HasPrefix(h.Normal, "x-amz")
+// SignUrl adds a query string signature to the URL of an object, allowing
+// GET requests for lifetime seconds.
+//
+// Deprecated: Use Presign, which supports other methods and headers.
+func (client *Client) SignUrl(req *url.URL, lifetime int64) error {
+	hreq, err := http.NewRequest("GET", req.String(), nil)
+	if err != nil {
+		return err
+	}
+	creds, err := client.Credentials.Retrieve()
+	if err != nil {
+		return err
+	}
+	signer := client.NewSigner()
+	signer.DisablePayloadSigning = true
+	u, _, err := signer.Presign(creds, hreq, time.Duration(lifetime)*time.Second)
+	if err != nil {
+		return err
+	}
+	req.RawQuery = u.RawQuery
+	return nil
 }
 
-func (hs normalizedHeaders) Len() int           { return len(hs) }
-func (hs normalizedHeaders) Less(i, j int) bool { return hs[i].Normal < hs[j].Normal } // TODO maybe not completely right
-func (hs normalizedHeaders) Swap(i, j int)      { hs[i], hs[j] = hs[j], hs[i] }
+type baseRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   io.ReadCloser
+}
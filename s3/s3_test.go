@@ -7,11 +7,448 @@ package s3
  */
 
 import (
-    "testing"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmatsuo/go-aws"
 )
 
+// A hand-written fake of the S3 object API, for path style requests. It is
+// not a recording of S3, so only the responses TestS3 relies on are faked.
+type fakeS3 struct {
+	objects map[string]*http.Response
+}
+
+func (fake *fakeS3) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		return fakeResponse(req, 403, "application/xml", "<Error><Code>AccessDenied</Code><Message>Access Denied</Message><RequestId>FAKE</RequestId></Error>"), nil
+	}
+	obj := fake.objects[req.URL.Path]
+	switch {
+	case req.Method == "PUT":
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		fake.objects[req.URL.Path] = fakeResponse(req, 200, req.Header.Get("Content-Type"), string(body))
+		resp := fakeResponse(req, 200, "", "")
+		resp.Header.Set("Etag", fmt.Sprintf(`"%x"`, md5.Sum(body)))
+		return resp, nil
+	case obj == nil:
+		return fakeResponse(req, 404, "application/xml", "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message><RequestId>FAKE</RequestId></Error>"), nil
+	case req.Method == "GET":
+		body, _ := ioutil.ReadAll(obj.Body)
+		obj.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return fakeResponse(req, 200, obj.Header.Get("Content-Type"), string(body)), nil
+	case req.Method == "DELETE":
+		delete(fake.objects, req.URL.Path)
+		return fakeResponse(req, 204, "", ""), nil
+	}
+	return fakeResponse(req, 405, "", ""), nil
+}
+
+func fakeResponse(req *http.Request, code int, contentType, body string) *http.Response {
+	resp := &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header),
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+	if contentType != "" {
+		resp.Header.Set("Content-Type", contentType)
+	}
+	return resp
+}
 
 func TestS3(t *testing.T) {
+	client := NewClient(&aws.Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}, USStandard)
+	client.HTTPClient = aws.NewHTTPClient(&aws.HTTPOptions{Transport: &fakeS3{objects: make(map[string]*http.Response)}})
+	const bucket = "go-aws-test"
+
+	put, err := client.PutObject(bucket, "hello.txt").ContentType("text/plain").Content([]byte("hello, world")).Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if put.StatusCode() != 200 || put.Header.Get("Etag") == "" {
+		t.Errorf("put %s %q", put.Status(), put.Header)
+	}
+
+	get, err := client.GetObject(bucket, "hello.txt").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(get.Body)
+	get.Body.Close()
+	if err != nil || string(body) != "hello, world" || get.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("get %q %q %v", body, get.Header, err)
+	}
+
+	del, err := client.DeleteObject(bucket, "hello.txt").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	del.Body.Close()
+	if del.StatusCode() != 204 {
+		t.Errorf("delete %s", del.Status())
+	}
+
+	_, err = client.GetObject(bucket, "hello.txt").Exec()
+	var s3err *Error
+	if !errors.Is(err, NoSuchKey) || !errors.As(err, &s3err) || s3err.RequestId == "" {
+		t.Errorf("get deleted object: %v", err)
+	}
+}
+
+func testClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	region := &aws.Region{Name: "us-east-1", Protocol: "http", Endpoint: strings.TrimPrefix(server.URL, "http://")}
+	client := NewClient(&aws.Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}, region)
+	client.Retryer.BaseDelay = time.Millisecond
+	return client
+}
+
+func TestPutObjectRetry(t *testing.T) {
+	attempts := 0
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "hello" {
+			t.Errorf("attempt %d: body %q", attempts, body)
+		}
+		if attempts == 1 {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>")
+			return
+		}
+		w.Header().Set("Etag", `"etag"`)
+	})
+	put, err := client.PutObject("bucket", "key").Content([]byte("hello")).Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if put.StatusCode() != 200 || attempts != 2 {
+		t.Errorf("status %d after %d attempts", put.StatusCode(), attempts)
+	}
+	// Attempts do not share a reader, which the transport may still be
+	// reading after an attempt fails.
+	request := client.PutObject("bucket", "key").Content([]byte("hello"))
+	req1, _ := request.Request(client.Region)
+	req2, _ := request.Request(client.Region)
+	req1.Body.Read(make([]byte, 2))
+	if body, _ := ioutil.ReadAll(req2.Body); string(body) != "hello" || req2.GetBody == nil {
+		t.Errorf("second request body %q", body)
+	}
+}
+
+func TestGetObjectRetryHeaders(t *testing.T) {
+	attempts := 0
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.Header.Get("Range") != "bytes=0-4" {
+			t.Errorf("attempt %d: range %q", attempts, r.Header["Range"])
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("X-First-Attempt") != "" {
+			t.Errorf("attempt %d: headers of attempt 1: %q", attempts, r.Header)
+		}
+		fmt.Fprint(w, "hello")
+	})
+	first := true
+	client.Handlers.Add(aws.BuildPhase, "first", func(next aws.Handler) aws.Handler {
+		return func(op *aws.Operation) error {
+			err := next(op)
+			if err == nil && first {
+				op.Request.Header.Set("X-First-Attempt", "1")
+				first = false
+			}
+			return err
+		}
+	})
+	get, err := client.GetObject("bucket", "key").Range("bytes=0-4").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	get.Body.Close()
+	if attempts != 2 {
+		t.Errorf("%d attempts", attempts)
+	}
+}
+
+func TestGetObjectCancel(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bucket/slow" {
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, "partial")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	get, err := client.GetObject("bucket", "key").ExecContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer get.Body.Close()
+	p := make([]byte, len("partial"))
+	_, err = io.ReadFull(get.Body, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	_, err = ioutil.ReadAll(get.Body)
+	if err == nil {
+		t.Errorf("expected body read to fail after cancellation")
+	}
 
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.GetObject("bucket", "slow").ExecContext(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline error: %v", err)
+	}
 }
 
+func TestErrors(t *testing.T) {
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Amz-Request-Id", "REQID")
+		w.Header().Set("X-Amz-Id-2", "HOSTID")
+		switch r.URL.Path {
+		case "/bucket/missing":
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message><RequestId>BODYREQID</RequestId></Error>")
+		case "/bucket/head":
+			w.WriteHeader(http.StatusNotFound)
+		case "/bucket/proxy":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "<html>bad request</html>")
+		}
+	})
+
+	_, err := client.GetObject("bucket", "missing").Exec()
+	if !errors.Is(err, NoSuchKey) || errors.Is(err, NoSuchBucket) {
+		t.Errorf("error %v", err)
+	}
+	var apierr aws.APIError
+	if !errors.As(err, &apierr) {
+		t.Fatalf("error %T", err)
+	}
+	if apierr.HTTPStatusCode() != 404 || apierr.RequestID() != "BODYREQID" || apierr.HostID() != "HOSTID" ||
+		apierr.ErrorMessage() != "The specified key does not exist." || apierr.Retryable() {
+		t.Errorf("error %v", err)
+	}
+
+	_, err = client.GetObject("bucket", "head").Exec()
+	var s3err *Error
+	if !errors.As(err, &s3err) || !errors.Is(err, NotFound) || s3err.RequestId != "REQID" {
+		t.Errorf("error %v", err)
+	}
+
+	_, err = client.GetObject("bucket", "proxy").Exec()
+	if !errors.As(err, &s3err) || s3err.Code != "BadRequest" || s3err.Message != "<html>bad request</html>" {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestNewClientConfig(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config")
+	err := ioutil.WriteFile(filename, []byte(`
+[profile virtual]
+region = eu-west-1
+aws_access_key_id = AKID
+aws_secret_access_key = SECRET
+max_attempts = 7
+s3 =
+    addressing_style = virtual
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", filename)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	for _, name := range []string{"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_ENDPOINT_URL", "AWS_MAX_ATTEMPTS"} {
+		t.Setenv(name, "")
+	}
+	config, err := aws.LoadConfig("virtual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClientConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Retryer.MaxAttempts != 7 || client.Region.Name != "eu-west-1" {
+		t.Errorf("client %+v", client.Client)
+	}
+	req, err := client.Request(client.GetObject("bucket", "a/key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.String() != "https://bucket."+client.Region.Endpoint+"/a/key" {
+		t.Errorf("url %v", req.URL)
+	}
+	if bucket, key := client.splitURL(req.URL); bucket != "bucket" || key != "a/key" {
+		t.Errorf("split %q %q", bucket, key)
+	}
+}
+
+func TestAddressingStyle(t *testing.T) {
+	client := NewClient(nil, &aws.Region{Protocol: "https", Endpoint: "s3.amazonaws.com", Name: aws.USEast1})
+	for _, test := range []struct {
+		style, bucket, want string
+	}{
+		{"", "bucket", "https://s3.amazonaws.com/bucket/key"},
+		{"path", "bucket", "https://s3.amazonaws.com/bucket/key"},
+		{"virtual", "bucket", "https://bucket.s3.amazonaws.com/key"},
+		{"virtual", "my.bucket", "https://my.bucket.s3.amazonaws.com/key"},
+		{"auto", "bucket", "https://bucket.s3.amazonaws.com/key"},
+		{"auto", "my.bucket", "https://s3.amazonaws.com/my.bucket/key"},
+		{"auto", "My_Bucket", "https://s3.amazonaws.com/My_Bucket/key"},
+	} {
+		client.AddressingStyle = test.style
+		if u := client.url(client.Region, "/"+test.bucket+"/key"); u.String() != test.want {
+			t.Errorf("%s %s: %v", test.style, test.bucket, u)
+		}
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://s3.amazonaws.com/bucket/logs/2013/05/24.log", nil)
+	op := &aws.Operation{Request: req}
+	for _, test := range []struct {
+		key  func(*aws.Operation) string
+		want string
+	}{
+		{BucketKey, "s3.amazonaws.com/bucket"},
+		{PrefixKey(1), "s3.amazonaws.com/bucket/logs"},
+		{PrefixKey(2), "s3.amazonaws.com/bucket/logs/2013"},
+		{PrefixKey(5), "s3.amazonaws.com/bucket/logs/2013/05"},
+	} {
+		if got := test.key(op); got != test.want {
+			t.Errorf("key %q want %q", got, test.want)
+		}
+	}
+
+	req, _ = http.NewRequest("GET", "https://bucket.s3.amazonaws.com/logs/2013/05/24.log", nil)
+	op = &aws.Operation{Request: req, Attributes: map[string]interface{}{AttrBucket: "bucket"}}
+	if got := PrefixKey(1)(op); got != "s3.amazonaws.com/bucket/logs" {
+		t.Errorf("virtual-hosted key %q", got)
+	}
+}
+
+func TestMetricsOperationNames(t *testing.T) {
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	})
+	metrics := aws.NewPrometheusMetrics()
+	client.Metrics = metrics
+	if _, err := client.PutObject("bucket", "key").Content([]byte("hello")).Exec(); err != nil {
+		t.Fatal(err)
+	}
+	get, err := client.GetObject("bucket", "key").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(get.Body)
+	get.Body.Close()
+	del, err := client.DeleteObject("bucket", "key").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	del.Body.Close()
+	var buf strings.Builder
+	metrics.WriteTo(&buf)
+	for _, want := range []string{
+		`aws_client_attempts_total{service="s3",operation="DeleteObject"} 1`,
+		`aws_client_attempts_total{service="s3",operation="GetObject"} 1`,
+		`aws_client_attempts_total{service="s3",operation="PutObject"} 1`,
+		`aws_client_sent_bytes_total{service="s3",operation="PutObject"} 5`,
+		`aws_client_received_bytes_total{service="s3",operation="GetObject"} 4`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	for path, want := range map[string][2]string{
+		"/bucket/dir/key": {"bucket", "dir/key"},
+		"/bucket":         {"bucket", ""},
+		"/":               {"", ""},
+	} {
+		if bucket, key := splitPath(path); bucket != want[0] || key != want[1] {
+			t.Errorf("%q: %q %q", path, bucket, key)
+		}
+	}
+}
+
+func TestPresign(t *testing.T) {
+	client := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("X-Amz-Signature") == "" || r.Header.Get("Authorization") != "" {
+			t.Errorf("request not presigned")
+		}
+		if r.Method == "PUT" && r.Header.Get("X-Amz-Metadata-Directive") != "REPLACE" {
+			t.Errorf("metadata directive %q", r.Header.Get("X-Amz-Metadata-Directive"))
+		}
+	})
+	u, header, err := client.Presign(client.PutObject("bucket", "key").MetadataDirective("REPLACE"), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(u.RawQuery, "X-Amz-SignedHeaders=host%3Bx-amz-metadata-directive") || header.Get("X-Amz-Metadata-Directive") != "REPLACE" {
+		t.Errorf("url %v headers %v", u, header)
+	}
+	req, _ := http.NewRequest("PUT", u.String(), strings.NewReader("any body"))
+	req.Header = header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	get, _ := http.NewRequest("GET", "http://"+client.Region.Endpoint+"/bucket/key", nil)
+	if err := client.SignUrl(get.URL, 3600); err != nil {
+		t.Fatal(err)
+	}
+	if get.URL.Query().Get("X-Amz-Expires") != "3600" {
+		t.Errorf("url %v", get.URL)
+	}
+	resp, err = http.Get(get.URL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
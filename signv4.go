@@ -0,0 +1,308 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// The algorithm identifier for Signature Version 4.
+	SignV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// The payload hash used when the request body is not signed.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// The hash of an empty payload.
+	EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	sigv4DateFormat = "20060102"
+	sigv4TimeFormat = "20060102T150405Z"
+)
+
+// Headers which are never included in a signature because proxies and
+// transports are free to modify them.
+var sigv4IgnoredHeaders = map[string]bool{
+	"authorization":   true,
+	"user-agent":      true,
+	"x-amzn-trace-id": true,
+	"traceparent":     true,
+	"expect":          true,
+}
+
+// Signer signs http requests using AWS Signature Version 4.
+// http://docs.aws.amazon.com/general/latest/gr/signature-version-4.html
+type Signer struct {
+	Service string // service name used in the credential scope (e.g. "s3")
+	Region  string // region name used in the credential scope (e.g. "us-east-1")
+
+	// S3 does not normalize or double-escape request paths. Other services
+	// remove dot segments and duplicate slashes before escaping twice.
+	DisableURIPathEscaping bool
+
+	// When true the payload hash is also sent in the X-Amz-Content-Sha256
+	// header, as S3 requires.
+	ContentSha256Header bool
+
+	// When true the payload is not hashed and UNSIGNED-PAYLOAD is signed
+	// instead. S3 accepts unsigned payloads, which presigned URLs need.
+	DisablePayloadSigning bool
+
+	// The clock used to time stamp signatures. Defaults to time.Now.
+	Now func() time.Time
+}
+
+func NewSigner(service string, region *Region) *Signer {
+	return &Signer{
+		Service: service,
+		Region:  region.Name,
+	}
+}
+
+func (signer *Signer) now() time.Time {
+	if signer.Now != nil {
+		return signer.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Sign adds the X-Amz-Date and Authorization headers to req, and the
+// X-Amz-Security-Token header for temporary credentials. If req has an
+// X-Amz-Content-Sha256 header its value is used as the payload hash,
+// otherwise the body is read (and replaced) to compute the hash.
+func (signer *Signer) Sign(creds *Credentials, req *http.Request) error {
+	_, _, err := signer.sign(creds, req)
+	return err
+}
+
+// Like Sign but also returns the canonical request and string to sign.
+func (signer *Signer) sign(creds *Credentials, req *http.Request) (creq, tosign string, err error) {
+	if creds.Expired() {
+		return "", "", ErrCredentialsExpired
+	}
+	t := signer.now()
+	payload, err := signer.payloadHash(req)
+	if err != nil {
+		return "", "", err
+	}
+	if signer.ContentSha256Header {
+		req.Header.Set("X-Amz-Content-Sha256", payload)
+	}
+	req.Header.Set("X-Amz-Date", t.Format(sigv4TimeFormat))
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	} else {
+		req.Header.Del("X-Amz-Security-Token")
+	}
+	req.Header.Del("Authorization")
+
+	creq, signed := signer.canonicalRequest(req, payload)
+	scope := signer.scope(t)
+	tosign = signer.stringToSign(t, scope, creq)
+	signature := hex.EncodeToString(hmacSHA256(signer.key(creds, t), tosign))
+
+	auth := SignV4Algorithm
+	auth += " Credential=" + creds.AccessKeyId + "/" + scope
+	auth += ", SignedHeaders=" + signed
+	auth += ", Signature=" + signature
+	req.Header.Set("Authorization", auth)
+	return creq, tosign, nil
+}
+
+func (signer *Signer) payloadHash(req *http.Request) (string, error) {
+	if h := req.Header.Get("X-Amz-Content-Sha256"); h != "" {
+		return h, nil
+	}
+	if signer.DisablePayloadSigning {
+		return UnsignedPayload, nil
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return EmptyPayloadHash, nil
+	}
+	var body io.ReadCloser
+	var err error
+	if req.GetBody != nil {
+		body, err = req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		defer body.Close()
+	} else {
+		p, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(p))
+		body = ioutil.NopCloser(bytes.NewReader(p))
+	}
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Returns the canonical request and the list of signed headers.
+func (signer *Signer) canonicalRequest(req *http.Request, payload string) (string, string) {
+	canonheaders, signed := canonicalHeaders(req)
+
+	creq := req.Method
+	creq += "\n"
+	creq += signer.canonicalURI(req)
+	creq += "\n"
+	creq += canonicalQuery(req)
+	creq += "\n"
+	creq += canonheaders
+	creq += "\n"
+	creq += signed
+	creq += "\n"
+	creq += payload
+	return creq, signed
+}
+
+// Returns the canonical headers and the list of signed headers.
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string][]string{"host": {host}}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if sigv4IgnoredHeaders[name] || name == "host" {
+			continue
+		}
+		headers[name] = append(headers[name], values...)
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonheaders bytes.Buffer
+	for _, name := range names {
+		values := make([]string, len(headers[name]))
+		for i, v := range headers[name] {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		canonheaders.WriteString(name)
+		canonheaders.WriteString(":")
+		canonheaders.WriteString(strings.Join(values, ","))
+		canonheaders.WriteString("\n")
+	}
+	return canonheaders.String(), strings.Join(names, ";")
+}
+
+func (signer *Signer) canonicalURI(req *http.Request) string {
+	path := req.URL.Path
+	if path == "" {
+		return "/"
+	}
+	if signer.DisableURIPathEscaping {
+		return uriEncode(path, false)
+	}
+	return uriEncode(uriEncode(normalizePath(path), false), false)
+}
+
+// Removes dot segments and duplicate slashes from an absolute path, keeping
+// a trailing slash.
+func normalizePath(path string) string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		switch segment {
+		case "", ".":
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+		default:
+			segments = append(segments, segment)
+		}
+	}
+	normal := "/" + strings.Join(segments, "/")
+	last := path[strings.LastIndex(path, "/")+1:]
+	if len(segments) > 0 && (last == "" || last == "." || last == "..") {
+		normal += "/"
+	}
+	return normal
+}
+
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func (signer *Signer) scope(t time.Time) string {
+	return t.Format(sigv4DateFormat) + "/" + signer.Region + "/" + signer.Service + "/aws4_request"
+}
+
+func (signer *Signer) stringToSign(t time.Time, scope, creq string) string {
+	hash := sha256.Sum256([]byte(creq))
+	tosign := SignV4Algorithm
+	tosign += "\n"
+	tosign += t.Format(sigv4TimeFormat)
+	tosign += "\n"
+	tosign += scope
+	tosign += "\n"
+	tosign += hex.EncodeToString(hash[:])
+	return tosign
+}
+
+func (signer *Signer) key(creds *Credentials, t time.Time) []byte {
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), t.Format(sigv4DateFormat))
+	key = hmacSHA256(key, signer.Region)
+	key = hmacSHA256(key, signer.Service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	hash := hmac.New(sha256.New, key)
+	hash.Write([]byte(data))
+	return hash.Sum(nil)
+}
+
+// URI encode every byte except the unreserved characters. Slashes are left
+// alone unless encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9':
+			buf.WriteByte(c)
+		case c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
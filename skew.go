@@ -0,0 +1,65 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Error codes returned when a request is signed with a time too far from
+// the service's clock.
+var skewCodes = map[string]bool{
+	"RequestTimeTooSkewed":      true,
+	"RequestExpired":            true,
+	"RequestInTheFuture":        true,
+	"InvalidSignatureException": true,
+	"SignatureDoesNotMatch":     true,
+	"AuthFailure":               true,
+}
+
+// Offsets smaller than this are not corrected because the Date header only
+// has a precision of one second and services tolerate minutes of skew.
+const skewTolerance = time.Minute
+
+// ClockOffset returns the correction added to the signer's clock, learned
+// from the Date header of responses to requests rejected for clock skew.
+func (client *Client) ClockOffset() time.Duration {
+	return time.Duration(client.clockOffset.Load())
+}
+
+// Now returns the time used to sign requests: the signer's clock corrected
+// by ClockOffset.
+func (client *Client) Now() time.Time {
+	return client.clock().Add(client.ClockOffset())
+}
+
+func (client *Client) clock() time.Time {
+	if client.Signer.Now != nil {
+		return client.Signer.Now()
+	}
+	return time.Now()
+}
+
+// Updates the clock offset when err is a clock skew error and resp has the
+// service's time. Returns true if the request should be signed again.
+func (client *Client) correctSkew(resp *http.Response, err error) bool {
+	var coder ErrorCoder
+	if resp == nil || !errors.As(err, &coder) || !skewCodes[coder.ErrorCode()] {
+		return false
+	}
+	date, perr := http.ParseTime(resp.Header.Get("Date"))
+	if perr != nil {
+		return false
+	}
+	offset := date.Sub(client.clock())
+	diff := offset - client.ClockOffset()
+	if diff > -skewTolerance && diff < skewTolerance {
+		return false
+	}
+	client.clockOffset.Store(int64(offset))
+	return true
+}
@@ -0,0 +1,67 @@
+package aws
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+type testCodedError string
+
+func (err testCodedError) Error() string     { return string(err) }
+func (err testCodedError) ErrorCode() string { return string(err) }
+
+func TestClientClockSkew(t *testing.T) {
+	serverTime := time.Date(2013, 5, 24, 12, 0, 0, 0, time.UTC)
+	requests := 0
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		signed, err := time.Parse(sigv4TimeFormat, r.Header.Get("X-Amz-Date"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Date", serverTime.Format(http.TimeFormat))
+		if d := serverTime.Sub(signed); d > 15*time.Minute || d < -15*time.Minute {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	})
+	client.Retryer = nil
+	client.Signer.Now = func() time.Time { return serverTime.Add(-time.Hour) }
+	client.ParseError = func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusForbidden {
+			resp.Body.Close()
+			return testCodedError("RequestTimeTooSkewed")
+		}
+		return nil
+	}
+
+	op := testOperation(client)
+	if err := client.Run(op); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || op.Attempt != 2 {
+		t.Errorf("%d requests, %d attempts", requests, op.Attempt)
+	}
+	if client.ClockOffset() != time.Hour {
+		t.Errorf("offset %v", client.ClockOffset())
+	}
+	if !client.Now().Equal(serverTime) {
+		t.Errorf("now %v", client.Now())
+	}
+
+	requests = 0
+	if err := client.Run(testOperation(client)); err != nil || requests != 1 {
+		t.Errorf("corrected clock: %d requests, error %v", requests, err)
+	}
+
+	// A skew error which the offset does not explain is returned.
+	serverTime = serverTime.Add(10 * time.Second)
+	client.ParseError = func(resp *http.Response) error {
+		resp.Body.Close()
+		return testCodedError("RequestTimeTooSkewed")
+	}
+	requests = 0
+	if err := client.Run(testOperation(client)); err == nil || requests != 1 {
+		t.Errorf("%d requests, error %v", requests, err)
+	}
+}
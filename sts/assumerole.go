@@ -0,0 +1,70 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sts
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+type AssumeRole struct {
+	base   baseRequest
+	client *Client
+}
+type AssumeRoleResult struct {
+	Credentials      *aws.Credentials `xml:"AssumeRoleResult>Credentials"`
+	AssumedRoleUser  AssumedRoleUser  `xml:"AssumeRoleResult>AssumedRoleUser"`
+	PackedPolicySize int              `xml:"AssumeRoleResult>PackedPolicySize"`
+	ResponseMetadata
+}
+
+func (client *Client) AssumeRole(roleArn, sessionName string) *AssumeRole {
+	request := &AssumeRole{
+		base:   newBaseRequest("AssumeRole"),
+		client: client,
+	}
+	request.base.Params.Set("RoleArn", roleArn)
+	request.base.Params.Set("RoleSessionName", sessionName)
+	return request
+}
+
+func (request *AssumeRole) Exec() (*AssumeRoleResult, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *AssumeRole) ExecContext(ctx context.Context) (*AssumeRoleResult, error) {
+	result := new(AssumeRoleResult)
+	_, err := request.client.run(ctx, request, result, false)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (request *AssumeRole) Request(region *aws.Region) (*http.Request, error) {
+	return request.base.Request(region)
+}
+
+func (request *AssumeRole) ExternalId(id string) *AssumeRole {
+	request.base.Params.Set("ExternalId", id)
+	return request
+}
+func (request *AssumeRole) MFA(serial, token string) *AssumeRole {
+	request.base.Params.Set("SerialNumber", serial)
+	request.base.Params.Set("TokenCode", token)
+	return request
+}
+func (request *AssumeRole) Policy(policy string) *AssumeRole {
+	request.base.Params.Set("Policy", policy)
+	return request
+}
+func (request *AssumeRole) Duration(lifetime time.Duration) *AssumeRole {
+	request.base.Params.Set("DurationSeconds", strconv.Itoa(int(lifetime/time.Second)))
+	return request
+}
@@ -0,0 +1,70 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sts
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+// AssumeRoleWithWebIdentity requests are not signed; the client needs no
+// credentials.
+type AssumeRoleWithWebIdentity struct {
+	base   baseRequest
+	client *Client
+}
+type AssumeRoleWithWebIdentityResult struct {
+	Credentials                 *aws.Credentials `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	AssumedRoleUser             AssumedRoleUser  `xml:"AssumeRoleWithWebIdentityResult>AssumedRoleUser"`
+	SubjectFromWebIdentityToken string           `xml:"AssumeRoleWithWebIdentityResult>SubjectFromWebIdentityToken"`
+	Provider                    string           `xml:"AssumeRoleWithWebIdentityResult>Provider"`
+	Audience                    string           `xml:"AssumeRoleWithWebIdentityResult>Audience"`
+	ResponseMetadata
+}
+
+func (client *Client) AssumeRoleWithWebIdentity(roleArn, sessionName, token string) *AssumeRoleWithWebIdentity {
+	request := &AssumeRoleWithWebIdentity{
+		base:   newBaseRequest("AssumeRoleWithWebIdentity"),
+		client: client,
+	}
+	request.base.Params.Set("RoleArn", roleArn)
+	request.base.Params.Set("RoleSessionName", sessionName)
+	request.base.Params.Set("WebIdentityToken", token)
+	return request
+}
+
+func (request *AssumeRoleWithWebIdentity) Exec() (*AssumeRoleWithWebIdentityResult, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *AssumeRoleWithWebIdentity) ExecContext(ctx context.Context) (*AssumeRoleWithWebIdentityResult, error) {
+	result := new(AssumeRoleWithWebIdentityResult)
+	_, err := request.client.run(ctx, request, result, true)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (request *AssumeRoleWithWebIdentity) Request(region *aws.Region) (*http.Request, error) {
+	return request.base.Request(region)
+}
+
+func (request *AssumeRoleWithWebIdentity) ProviderId(id string) *AssumeRoleWithWebIdentity {
+	request.base.Params.Set("ProviderId", id)
+	return request
+}
+func (request *AssumeRoleWithWebIdentity) Policy(policy string) *AssumeRoleWithWebIdentity {
+	request.base.Params.Set("Policy", policy)
+	return request
+}
+func (request *AssumeRoleWithWebIdentity) Duration(lifetime time.Duration) *AssumeRoleWithWebIdentity {
+	request.base.Params.Set("DurationSeconds", strconv.Itoa(int(lifetime/time.Second)))
+	return request
+}
@@ -0,0 +1,47 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sts
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+type GetCallerIdentity struct {
+	base   baseRequest
+	client *Client
+}
+type GetCallerIdentityResult struct {
+	Account string `xml:"GetCallerIdentityResult>Account"`
+	Arn     string `xml:"GetCallerIdentityResult>Arn"`
+	UserId  string `xml:"GetCallerIdentityResult>UserId"`
+	ResponseMetadata
+}
+
+func (client *Client) GetCallerIdentity() *GetCallerIdentity {
+	return &GetCallerIdentity{
+		base:   newBaseRequest("GetCallerIdentity"),
+		client: client,
+	}
+}
+
+func (request *GetCallerIdentity) Exec() (*GetCallerIdentityResult, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *GetCallerIdentity) ExecContext(ctx context.Context) (*GetCallerIdentityResult, error) {
+	result := new(GetCallerIdentityResult)
+	_, err := request.client.run(ctx, request, result, false)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (request *GetCallerIdentity) Request(region *aws.Region) (*http.Request, error) {
+	return request.base.Request(region)
+}
@@ -0,0 +1,57 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sts
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+type GetSessionToken struct {
+	base   baseRequest
+	client *Client
+}
+type GetSessionTokenResult struct {
+	Credentials *aws.Credentials `xml:"GetSessionTokenResult>Credentials"`
+	ResponseMetadata
+}
+
+func (client *Client) GetSessionToken() *GetSessionToken {
+	return &GetSessionToken{
+		base:   newBaseRequest("GetSessionToken"),
+		client: client,
+	}
+}
+
+func (request *GetSessionToken) Exec() (*GetSessionTokenResult, error) {
+	return request.ExecContext(context.Background())
+}
+
+func (request *GetSessionToken) ExecContext(ctx context.Context) (*GetSessionTokenResult, error) {
+	result := new(GetSessionTokenResult)
+	_, err := request.client.run(ctx, request, result, false)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (request *GetSessionToken) Request(region *aws.Region) (*http.Request, error) {
+	return request.base.Request(region)
+}
+
+func (request *GetSessionToken) MFA(serial, token string) *GetSessionToken {
+	request.base.Params.Set("SerialNumber", serial)
+	request.base.Params.Set("TokenCode", token)
+	return request
+}
+func (request *GetSessionToken) Duration(lifetime time.Duration) *GetSessionToken {
+	request.base.Params.Set("DurationSeconds", strconv.Itoa(int(lifetime/time.Second)))
+	return request
+}
@@ -0,0 +1,199 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sts
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+// LoadConfig resolves the named profile as aws.LoadConfig does, assuming the
+// roles of profiles with a role_arn.
+func LoadConfig(profile string) (*aws.Config, error) {
+	loader := &aws.ConfigLoader{AssumeRole: ProfileProvider}
+	return loader.Load(profile)
+}
+
+// ProfileProvider is an aws.AssumeRoleFunc. It assumes the role of a profile
+// with a role_arn, prompting for an MFA code if the profile has an
+// mfa_serial.
+func ProfileProvider(source aws.CredentialsProvider, profile *aws.Profile) (aws.CredentialsProvider, error) {
+	region := Global
+	if profile.Region != "" {
+		var err error
+		region, err = Region(profile.Region)
+		if err != nil {
+			return nil, err
+		}
+	}
+	p := NewAssumeRoleProvider(source, region, profile.RoleArn)
+	p.RoleSessionName = profile.RoleSessionName
+	p.ExternalId = profile.ExternalId
+	p.Duration = time.Duration(profile.DurationSeconds) * time.Second
+	if profile.MfaSerial != "" {
+		p.SerialNumber = profile.MfaSerial
+		p.TokenCode = StdinTokenCode
+	}
+	return p, nil
+}
+
+// StdinTokenCode prompts for an MFA code on stderr and reads it from stdin.
+func StdinTokenCode() (string, error) {
+	fmt.Fprint(os.Stderr, "Enter MFA code: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func defaultSessionName() string {
+	return "go-aws-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+}
+
+// AssumeRoleProvider assumes a role using the credentials of Client and
+// returns the temporary credentials. Credentials are cached and refreshed
+// ExpiryWindow before they expire. Safe for concurrent use.
+type AssumeRoleProvider struct {
+	Client          *Client
+	RoleArn         string
+	RoleSessionName string // defaults to a unique name
+	ExternalId      string
+	Policy          string
+	Duration        time.Duration // zero for the service default (one hour)
+	ExpiryWindow    time.Duration // defaults to aws.DefaultExpiryWindow
+
+	// When SerialNumber is set, TokenCode is called each time the role is
+	// assumed to get the current MFA code.
+	SerialNumber string
+	TokenCode    func() (string, error)
+
+	cache aws.CredentialsCache
+}
+
+// NewAssumeRoleProvider assumes roleArn with credentials from source.
+func NewAssumeRoleProvider(source aws.CredentialsProvider, region *aws.Region, roleArn string) *AssumeRoleProvider {
+	return &AssumeRoleProvider{
+		Client:  NewClient(source, region),
+		RoleArn: roleArn,
+	}
+}
+
+func (p *AssumeRoleProvider) Retrieve() (*aws.Credentials, error) {
+	window := p.ExpiryWindow
+	if window == 0 {
+		window = aws.DefaultExpiryWindow
+	}
+	return p.cache.Retrieve(window, p.fetch)
+}
+
+func (p *AssumeRoleProvider) fetch() (*aws.Credentials, error) {
+	name := p.RoleSessionName
+	if name == "" {
+		name = defaultSessionName()
+	}
+	request := p.Client.AssumeRole(p.RoleArn, name)
+	if p.ExternalId != "" {
+		request.ExternalId(p.ExternalId)
+	}
+	if p.Policy != "" {
+		request.Policy(p.Policy)
+	}
+	if p.Duration != 0 {
+		request.Duration(p.Duration)
+	}
+	if p.SerialNumber != "" {
+		if p.TokenCode == nil {
+			return nil, fmt.Errorf("sts: assume role %s: mfa serial without a token code function", p.RoleArn)
+		}
+		code, err := p.TokenCode()
+		if err != nil {
+			return nil, fmt.Errorf("sts: assume role %s: %v", p.RoleArn, err)
+		}
+		request.MFA(p.SerialNumber, code)
+	}
+	result, err := request.Exec()
+	if err != nil {
+		return nil, fmt.Errorf("sts: assume role %s: %v", p.RoleArn, err)
+	}
+	if result.Credentials == nil {
+		return nil, fmt.Errorf("sts: assume role %s: no credentials in response", p.RoleArn)
+	}
+	return result.Credentials, nil
+}
+
+// WebIdentityProvider exchanges an OIDC token (as used by EKS service
+// accounts) for role credentials. Credentials are cached and refreshed
+// ExpiryWindow before they expire. Safe for concurrent use.
+type WebIdentityProvider struct {
+	Client *Client
+
+	// Default to $AWS_ROLE_ARN, $AWS_WEB_IDENTITY_TOKEN_FILE and
+	// $AWS_ROLE_SESSION_NAME. The token file is read on every refresh.
+	RoleArn         string
+	TokenFile       string
+	RoleSessionName string
+
+	Duration     time.Duration // zero for the service default (one hour)
+	ExpiryWindow time.Duration // defaults to aws.DefaultExpiryWindow
+
+	cache aws.CredentialsCache
+}
+
+func NewWebIdentityProvider(region *aws.Region) *WebIdentityProvider {
+	return &WebIdentityProvider{Client: NewClient(nil, region)}
+}
+
+func (p *WebIdentityProvider) Retrieve() (*aws.Credentials, error) {
+	window := p.ExpiryWindow
+	if window == 0 {
+		window = aws.DefaultExpiryWindow
+	}
+	return p.cache.Retrieve(window, p.fetch)
+}
+
+func (p *WebIdentityProvider) fetch() (*aws.Credentials, error) {
+	arn := p.RoleArn
+	if arn == "" {
+		arn = os.Getenv("AWS_ROLE_ARN")
+	}
+	filename := p.TokenFile
+	if filename == "" {
+		filename = os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE")
+	}
+	name := p.RoleSessionName
+	if name == "" {
+		name = os.Getenv("AWS_ROLE_SESSION_NAME")
+	}
+	if name == "" {
+		name = defaultSessionName()
+	}
+	if arn == "" || filename == "" {
+		return nil, fmt.Errorf("sts: web identity: AWS_ROLE_ARN and AWS_WEB_IDENTITY_TOKEN_FILE not set")
+	}
+	token, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("sts: web identity: %v", err)
+	}
+	request := p.Client.AssumeRoleWithWebIdentity(arn, name, strings.TrimSpace(string(token)))
+	if p.Duration != 0 {
+		request.Duration(p.Duration)
+	}
+	result, err := request.Exec()
+	if err != nil {
+		return nil, fmt.Errorf("sts: web identity: assume role %s: %v", arn, err)
+	}
+	if result.Credentials == nil {
+		return nil, fmt.Errorf("sts: web identity: assume role %s: no credentials in response", arn)
+	}
+	return result.Credentials, nil
+}
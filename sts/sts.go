@@ -0,0 +1,118 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// API calls follow the conventions described in s3/s3.go.
+
+// Package sts is a client for the AWS Security Token Service.
+package sts
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+// The API version sent with every request.
+const Version = "2011-06-15"
+
+// The global endpoint, which signs in us-east-1.
+var Global = &aws.Region{Name: aws.USEast1, Protocol: "https", Endpoint: "sts.amazonaws.com"}
+
+// Region returns the regional STS endpoint for the named region (e.g.
+// "us-west-2").
+func Region(name string) (*aws.Region, error) {
+	return aws.ResolveEndpoint("sts", name)
+}
+
+// Client runs each API call through the phases of the embedded
+// *aws.Client, whose Handlers may be used to customize requests.
+type Client struct {
+	*aws.Client
+}
+
+// Credentials are retrieved from creds each time a request is signed. A
+// *aws.Credentials may be given for static keys. creds may be nil if only
+// AssumeRoleWithWebIdentity is used.
+func NewClient(creds aws.CredentialsProvider, region *aws.Region) *Client {
+	client := aws.NewClient("sts", creds, region)
+	client.ParseError = aws.QueryErrorParser("sts")
+	return &Client{client}
+}
+
+type Request interface {
+	Request(*aws.Region) (*http.Request, error)
+}
+
+// Error is returned for responses with a status other than 2xx.
+type Error = aws.QueryError
+
+type ResponseMetadata = aws.ResponseMetadata
+
+// Creates a signed request from req
+func (client *Client) Request(req Request) (*http.Request, error) {
+	hreq, err := req.Request(client.Region)
+	if err != nil {
+		return nil, err
+	}
+	err = client.Sign(hreq)
+	if err != nil {
+		return nil, err
+	}
+	return hreq, nil
+}
+
+func (client *Client) Do(req Request) (*http.Response, error) {
+	return client.DoContext(context.Background(), req)
+}
+
+// DoContext sends req, retrying failures according to client.Retryer. The
+// request is rebuilt and signed again for each attempt. Canceling ctx aborts
+// the request and any retries.
+func (client *Client) DoContext(ctx context.Context, req Request) (*http.Response, error) {
+	return client.run(ctx, req, nil, false)
+}
+
+// Sends req and decodes the response into result, which may be nil.
+func (client *Client) run(ctx context.Context, req Request, result interface{}, unsigned bool) (*http.Response, error) {
+	op := &aws.Operation{
+		Context:  ctx,
+		Name:     aws.OperationName(req),
+		Unsigned: unsigned,
+		Output:   result,
+		Build: func(op *aws.Operation) (err error) {
+			op.Request, err = req.Request(client.Region)
+			return err
+		},
+	}
+	if result != nil {
+		op.Unmarshal = func(op *aws.Operation) error {
+			return aws.DecodeQueryResponse(op.Response, op.Output)
+		}
+	}
+	err := client.Run(op)
+	return op.Response, err
+}
+
+type baseRequest struct {
+	Params url.Values
+}
+
+func newBaseRequest(action string) baseRequest {
+	params := make(url.Values, 8)
+	params.Set("Action", action)
+	params.Set("Version", Version)
+	return baseRequest{params}
+}
+
+func (base *baseRequest) Request(region *aws.Region) (*http.Request, error) {
+	return aws.NewQueryRequest(region, base.Params)
+}
+
+// The role assumed by AssumeRole and AssumeRoleWithWebIdentity.
+type AssumedRoleUser struct {
+	Arn           string
+	AssumedRoleId string
+}
@@ -0,0 +1,167 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sts
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bmatsuo/go-aws"
+)
+
+func testServer(t *testing.T) *aws.Region {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		action := r.PostForm.Get("Action")
+		signed := strings.HasPrefix(r.Header.Get("Authorization"), aws.SignV4Algorithm)
+		expires := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		switch {
+		case action == "AssumeRole" && signed && r.PostForm.Get("ExternalId") == "xid":
+			fmt.Fprintf(w, `<AssumeRoleResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleResult>
+    <Credentials>
+      <AccessKeyId>ASIAROLE</AccessKeyId>
+      <SecretAccessKey>SECRET</SecretAccessKey>
+      <SessionToken>TOKEN</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+    <AssumedRoleUser>
+      <Arn>%s/%s</Arn>
+      <AssumedRoleId>AROAEXAMPLE:%s</AssumedRoleId>
+    </AssumedRoleUser>
+  </AssumeRoleResult>
+  <ResponseMetadata><RequestId>req-1</RequestId></ResponseMetadata>
+</AssumeRoleResponse>`, expires, r.PostForm.Get("RoleArn"), r.PostForm.Get("RoleSessionName"), r.PostForm.Get("RoleSessionName"))
+		case action == "AssumeRoleWithWebIdentity" && !signed && r.PostForm.Get("WebIdentityToken") == "jwt":
+			fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse>
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIAWEB</AccessKeyId>
+      <SecretAccessKey>SECRET</SecretAccessKey>
+      <SessionToken>TOKEN</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, expires)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse>
+  <Error><Type>Sender</Type><Code>AccessDenied</Code><Message>denied</Message></Error>
+  <RequestId>req-2</RequestId>
+</ErrorResponse>`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return &aws.Region{Name: "us-east-1", Protocol: "http", Endpoint: strings.TrimPrefix(server.URL, "http://")}
+}
+
+func TestAssumeRoleProvider(t *testing.T) {
+	region := testServer(t)
+	source := &aws.Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}
+	p := NewAssumeRoleProvider(source, region, "arn:aws:iam::123456789012:role/demo")
+	p.ExternalId = "xid"
+	creds, err := p.Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "ASIAROLE" || creds.SessionToken != "TOKEN" || creds.Expiration.IsZero() {
+		t.Errorf("unexpected credentials: %#v", creds)
+	}
+
+	p = NewAssumeRoleProvider(source, region, "arn:aws:iam::123456789012:role/demo")
+	_, err = p.Retrieve()
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("expected AccessDenied: %v", err)
+	}
+}
+
+func TestAssumeRoleError(t *testing.T) {
+	client := NewClient(&aws.Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}, testServer(t))
+	_, err := client.AssumeRole("arn:aws:iam::123456789012:role/demo", "session").Exec()
+	stserr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stserr.Code != "AccessDenied" || stserr.RequestId != "req-2" {
+		t.Errorf("unexpected error: %#v", stserr)
+	}
+}
+
+func TestWebIdentityProvider(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(filename, []byte("jwt\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/web")
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", filename)
+	creds, err := NewWebIdentityProvider(testServer(t)).Retrieve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.AccessKeyId != "ASIAWEB" {
+		t.Errorf("unexpected credentials: %#v", creds)
+	}
+}
+
+func TestRequestLoggerRedacts(t *testing.T) {
+	var buf bytes.Buffer
+	client := NewClient(nil, testServer(t))
+	logger := &aws.RequestLogger{Logger: log.New(&buf, "", 0), LogBody: true}
+	client.Handlers.Add(aws.SendPhase, "log", logger.Middleware)
+	_, err := client.AssumeRoleWithWebIdentity("arn:aws:iam::123456789012:role/web", "session", "jwt").Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "jwt") || !strings.Contains(buf.String(), "WebIdentityToken=REDACTED") ||
+		!strings.Contains(buf.String(), "RoleSessionName=session") {
+		t.Errorf("logged:\n%s", buf.String())
+	}
+	for _, secret := range []string{"ASIAWEB", ">SECRET<", ">TOKEN<"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("logged credentials %q:\n%s", secret, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), "<SecretAccessKey>REDACTED</SecretAccessKey>") {
+		t.Errorf("response body not logged:\n%s", buf.String())
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config")
+	err := os.WriteFile(config, []byte(`
+[profile admin]
+role_arn = arn:aws:iam::123456789012:role/admin
+source_profile = admin
+aws_access_key_id = AKID
+aws_secret_access_key = SECRET
+region = eu-west-1
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", config)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(dir, "credentials"))
+	if _, err := aws.LoadConfig("admin"); err == nil || !strings.Contains(err.Error(), "sts.LoadConfig") {
+		t.Errorf("aws.LoadConfig: %v", err)
+	}
+	loaded, err := LoadConfig("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok := loaded.Credentials.(*AssumeRoleProvider)
+	if !ok || p.RoleArn != "arn:aws:iam::123456789012:role/admin" || p.Client.Region.Name != "eu-west-1" {
+		t.Errorf("credentials %#v", loaded.Credentials)
+	}
+}
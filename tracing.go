@@ -0,0 +1,155 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Tracer starts the spans of a client: one for each API call, named like
+// "s3.GetObject", and one for each attempt within it, named "attempt".
+// Implement it to bridge to a tracing system such as OpenTelemetry. A
+// tracer which propagates its trace to AWS returns a context carrying the
+// new span's TraceContext.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation being traced.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End(err error)
+}
+
+// Span attributes set by clients.
+const (
+	AttrService    = "aws.service"
+	AttrOperation  = "aws.operation"
+	AttrRegion     = "aws.region"
+	AttrAttempt    = "aws.attempt"
+	AttrRequestID  = "aws.request_id"
+	AttrStatusCode = "http.status_code"
+)
+
+// TraceContext identifies a span in a distributed trace.
+type TraceContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Sampled bool
+}
+
+type traceContextKey struct{}
+
+// ContextWithTrace returns a context whose requests carry the trace in
+// their X-Amzn-Trace-Id and traceparent headers.
+func ContextWithTrace(ctx context.Context, trace TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, trace)
+}
+
+// TraceFromContext returns the trace carried by ctx.
+func TraceFromContext(ctx context.Context) (TraceContext, bool) {
+	trace, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return trace, ok
+}
+
+// ParseTraceparent parses a W3C traceparent header.
+func ParseTraceparent(header string) (TraceContext, error) {
+	var trace TraceContext
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return trace, fmt.Errorf("aws: invalid traceparent %q", header)
+	}
+	if _, err := hex.Decode(trace.TraceID[:], []byte(parts[1])); err != nil {
+		return trace, fmt.Errorf("aws: invalid traceparent %q: %v", header, err)
+	}
+	if _, err := hex.Decode(trace.SpanID[:], []byte(parts[2])); err != nil {
+		return trace, fmt.Errorf("aws: invalid traceparent %q: %v", header, err)
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return trace, fmt.Errorf("aws: invalid traceparent %q: %v", header, err)
+	}
+	trace.Sampled = flags[0]&1 == 1
+	return trace, nil
+}
+
+// Traceparent returns the W3C traceparent header for the trace.
+func (trace TraceContext) Traceparent() string {
+	flags := "00"
+	if trace.Sampled {
+		flags = "01"
+	}
+	return "00-" + hex.EncodeToString(trace.TraceID[:]) + "-" + hex.EncodeToString(trace.SpanID[:]) + "-" + flags
+}
+
+// AmznTraceID returns the X-Amzn-Trace-Id header for the trace. The first
+// four bytes of the trace id are the X-Ray epoch time.
+func (trace TraceContext) AmznTraceID() string {
+	id := hex.EncodeToString(trace.TraceID[:])
+	sampled := "0"
+	if trace.Sampled {
+		sampled = "1"
+	}
+	return "Root=1-" + id[:8] + "-" + id[8:] + ";Parent=" + hex.EncodeToString(trace.SpanID[:]) + ";Sampled=" + sampled
+}
+
+// Adds the trace carried by ctx to req.
+func injectTrace(ctx context.Context, req *http.Request) {
+	trace, ok := TraceFromContext(ctx)
+	if !ok {
+		return
+	}
+	req.Header.Set("Traceparent", trace.Traceparent())
+	req.Header.Set("X-Amzn-Trace-Id", trace.AmznTraceID())
+}
+
+// The request id of a response or error.
+func requestID(resp *http.Response, err error) string {
+	var apierr APIError
+	if errors.As(err, &apierr) && apierr.RequestID() != "" {
+		return apierr.RequestID()
+	}
+	if resp == nil {
+		return ""
+	}
+	for _, key := range []string{"X-Amz-Request-Id", "X-Amzn-Requestid"} {
+		if id := resp.Header.Get(key); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func endSpan(span Span, op *Operation, err error) {
+	for key, value := range op.Attributes {
+		span.SetAttribute(key, value)
+	}
+	span.SetAttribute(AttrAttempt, op.Attempt)
+	if op.Response != nil {
+		span.SetAttribute(AttrStatusCode, op.Response.StatusCode)
+	}
+	if id := requestID(op.Response, err); id != "" {
+		span.SetAttribute(AttrRequestID, id)
+	}
+	span.End(err)
+}
+
+// A span which does nothing, used when the client has no Tracer.
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) End(err error)                              {}
+
+func (client *Client) startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if client.Tracer == nil {
+		return ctx, noopSpan{}
+	}
+	return client.Tracer.StartSpan(ctx, name)
+}
@@ -0,0 +1,182 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	ended bool
+	err   error
+}
+
+func (span *testSpan) SetAttribute(key string, value interface{}) { span.attrs[key] = value }
+func (span *testSpan) End(err error)                              { span.ended, span.err = true, err }
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+// Each span gets a span id equal to the number of spans started.
+func (tracer *testTracer) StartSpan(ctx context.Context, name string) (context.Context, Span) {
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	span := &testSpan{name: name, attrs: make(map[string]interface{})}
+	tracer.spans = append(tracer.spans, span)
+	trace, _ := TraceFromContext(ctx)
+	trace.SpanID[7] = byte(len(tracer.spans))
+	return ContextWithTrace(ctx, trace), span
+}
+
+func TestClientTracing(t *testing.T) {
+	var traceparents, amznTraceIDs []string
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get("Traceparent"))
+		amznTraceIDs = append(amznTraceIDs, r.Header.Get("X-Amzn-Trace-Id"))
+		w.Header().Set("X-Amzn-Requestid", "REQID")
+		if len(traceparents) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	client.ParseError = func(resp *http.Response) error {
+		if resp.StatusCode >= 300 {
+			resp.Body.Close()
+			return testCodedError("ServiceUnavailable")
+		}
+		return nil
+	}
+	tracer := new(testTracer)
+	client.Tracer = tracer
+
+	trace, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := testOperation(client)
+	op.Context = ContextWithTrace(context.Background(), trace)
+	op.Attributes = map[string]interface{}{"test.attr": "value"}
+	if err := client.Run(op); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("%d spans", len(tracer.spans))
+	}
+	call, first, second := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+	if call.name != "test.Test" || !call.ended || call.err != nil {
+		t.Errorf("call span %+v", call)
+	}
+	for key, value := range map[string]interface{}{
+		AttrService:    "test",
+		AttrOperation:  "Test",
+		AttrRegion:     USEast1,
+		AttrAttempt:    2,
+		AttrStatusCode: 200,
+		AttrRequestID:  "REQID",
+		"test.attr":    "value",
+	} {
+		if call.attrs[key] != value {
+			t.Errorf("call span %s = %v", key, call.attrs[key])
+		}
+	}
+	if first.name != "attempt" || first.attrs[AttrAttempt] != 1 || first.attrs[AttrStatusCode] != 503 || first.err == nil {
+		t.Errorf("first attempt span %+v", first)
+	}
+	if second.attrs[AttrAttempt] != 2 || second.err != nil || !second.ended {
+		t.Errorf("second attempt span %+v", second)
+	}
+
+	// Requests carry the attempt span as the parent.
+	want := []string{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba90202-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba90203-01",
+	}
+	if len(traceparents) != 2 || traceparents[0] != want[0] || traceparents[1] != want[1] {
+		t.Errorf("traceparent %q", traceparents)
+	}
+	if amznTraceIDs[1] != "Root=1-4bf92f35-77b34da6a3ce929d0e0e4736;Parent=00f067aa0ba90203;Sampled=1" {
+		t.Errorf("X-Amzn-Trace-Id %q", amznTraceIDs[1])
+	}
+}
+
+// Verifies the signature of a request received by a server.
+func testVerifySignature(t *testing.T, signer *Signer, creds *Credentials, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	i := strings.Index(auth, "SignedHeaders=")
+	if i < 0 {
+		t.Errorf("unsigned request: %q", auth)
+		return
+	}
+	signed := strings.TrimPrefix(auth[i:], "SignedHeaders=")
+	signed = signed[:strings.Index(signed, ",")]
+	req, _ := http.NewRequest(r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	for _, name := range strings.Split(signed, ";") {
+		if name != "host" {
+			req.Header[http.CanonicalHeaderKey(name)] = r.Header.Values(name)
+		}
+	}
+	now, _ := time.Parse(sigv4TimeFormat, r.Header.Get("X-Amz-Date"))
+	verifier := *signer
+	verifier.Now = func() time.Time { return now }
+	if err := verifier.Sign(creds, req); err != nil {
+		t.Error(err)
+	} else if req.Header.Get("Authorization") != auth {
+		t.Errorf("signature mismatch:\n%s\n%s", auth, req.Header.Get("Authorization"))
+	}
+}
+
+// Builders may reuse a header map across attempts, so trace headers must not
+// leave a stale signed value behind.
+func TestClientTracingRetrySignature(t *testing.T) {
+	var client *Client
+	attempts := 0
+	client = testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		testVerifySignature(t, client.NewSigner(), &Credentials{AccessKeyId: "AKID", SecretAccessKey: "SECRET"}, r)
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	client.Tracer = new(testTracer)
+	trace, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header := make(http.Header)
+	op := testOperation(client)
+	op.Context = ContextWithTrace(context.Background(), trace)
+	op.Build = func(op *Operation) (err error) {
+		op.Request, err = http.NewRequest("GET", client.Region.Url("", "/", nil).String(), nil)
+		if err == nil {
+			op.Request.Header = header
+		}
+		return err
+	}
+	if err := client.Run(op); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 2 {
+		t.Errorf("%d attempts", attempts)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+	} {
+		if _, err := ParseTraceparent(header); err == nil {
+			t.Errorf("%q: no error", header)
+		}
+	}
+	header := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00"
+	trace, err := ParseTraceparent(header)
+	if err != nil || trace.Sampled || trace.Traceparent() != header {
+		t.Errorf("%+v %v", trace, err)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+)
+
+// The version of this library.
+const Version = "0.1.0"
+
+// The User-Agent header of requests, followed by the application's
+// Client.UserAgent.
+var UserAgent = "go-aws/" + Version + " (" + runtime.Version() + "; " + runtime.GOOS + "/" + runtime.GOARCH + ")"
+
+// HTTPOptions configure the *http.Client returned by NewHTTPClient. Zero
+// values keep the defaults of http.DefaultTransport.
+type HTTPOptions struct {
+	// Sends requests when not nil, in which case the remaining transport
+	// options are ignored.
+	Transport http.RoundTripper
+
+	// Selects the proxy for each request. Defaults to
+	// http.ProxyFromEnvironment ($HTTPS_PROXY, $HTTP_PROXY and $NO_PROXY).
+	// Use http.ProxyURL for a fixed proxy.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// For custom certificate authorities (e.g. on-premises S3) or client
+	// certificates.
+	TLSConfig *tls.Config
+
+	DialTimeout           time.Duration
+	ResponseHeaderTimeout time.Duration
+
+	// Limits each attempt, including reading the response body. Zero means
+	// no limit.
+	Timeout time.Duration
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+}
+
+// NewHTTPClient returns an *http.Client for a service client's HTTPClient.
+// opts may be nil.
+func NewHTTPClient(opts *HTTPOptions) *http.Client {
+	if opts == nil {
+		opts = new(HTTPOptions)
+	}
+	if opts.Transport != nil {
+		return &http.Client{Transport: opts.Transport, Timeout: opts.Timeout}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.Proxy != nil {
+		transport.Proxy = opts.Proxy
+	}
+	if opts.TLSConfig != nil {
+		transport.TLSClientConfig = opts.TLSConfig
+	}
+	if opts.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: opts.DialTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = dialer.DialContext
+	}
+	if opts.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = opts.ResponseHeaderTimeout
+	}
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	return &http.Client{Transport: transport, Timeout: opts.Timeout}
+}
+
+// Returns the User-Agent header of the client's requests.
+func (client *Client) userAgent() string {
+	if client.UserAgent == "" {
+		return UserAgent
+	}
+	return UserAgent + " " + client.UserAgent
+}
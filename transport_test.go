@@ -0,0 +1,78 @@
+package aws
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	proxy := http.ProxyURL(&url.URL{Scheme: "http", Host: "proxy:3128"})
+	tlsConfig := &tls.Config{ServerName: "s3.example.com"}
+	client := NewHTTPClient(&HTTPOptions{
+		Proxy:                 proxy,
+		TLSConfig:             tlsConfig,
+		ResponseHeaderTimeout: time.Second,
+		Timeout:               time.Minute,
+		MaxIdleConns:          7,
+		MaxIdleConnsPerHost:   3,
+		IdleConnTimeout:       time.Hour,
+	})
+	transport := client.Transport.(*http.Transport)
+	if transport == http.DefaultTransport {
+		t.Fatalf("default transport modified")
+	}
+	u, _ := transport.Proxy(nil)
+	if u.Host != "proxy:3128" || transport.TLSClientConfig != tlsConfig {
+		t.Errorf("proxy %v tls %v", u, transport.TLSClientConfig)
+	}
+	if transport.ResponseHeaderTimeout != time.Second || client.Timeout != time.Minute ||
+		transport.MaxIdleConns != 7 || transport.MaxIdleConnsPerHost != 3 || transport.IdleConnTimeout != time.Hour {
+		t.Errorf("transport %+v", transport)
+	}
+
+	var rt http.RoundTripper = &http.Transport{}
+	if client := NewHTTPClient(&HTTPOptions{Transport: rt}); client.Transport != rt {
+		t.Errorf("transport %v", client.Transport)
+	}
+}
+
+func TestClientUserAgent(t *testing.T) {
+	block := make(chan struct{})
+	client := testServiceClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("slow") != "" {
+			<-block
+			return
+		}
+		want := "go-aws/" + Version + " ("
+		if ua := r.UserAgent(); !strings.HasPrefix(ua, want) || !strings.HasSuffix(ua, ") myapp/1.2") {
+			t.Errorf("User-Agent %q", ua)
+		}
+	})
+	defer close(block)
+	client.UserAgent = "myapp/1.2"
+	if err := client.Run(testOperation(client)); err != nil {
+		t.Fatal(err)
+	}
+
+	client.Retryer = nil
+	client.HTTPClient = NewHTTPClient(&HTTPOptions{ResponseHeaderTimeout: 10 * time.Millisecond})
+	op := testOperation(client)
+	build := op.Build
+	op.Build = func(op *Operation) error {
+		err := build(op)
+		if err == nil {
+			op.Request.URL.RawQuery = "slow=1"
+		}
+		return err
+	}
+	var neterr net.Error
+	if err := client.Run(op); !errors.As(err, &neterr) || !neterr.Timeout() {
+		t.Errorf("error %v", err)
+	}
+}
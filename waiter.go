@@ -0,0 +1,173 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Waiter states. An acceptor which matches ends the wait in its state,
+// except WaiterRetry which polls again.
+const (
+	WaiterSuccess = "success"
+	WaiterFailure = "failure"
+	WaiterRetry   = "retry"
+)
+
+// Waiter acceptor matchers.
+const (
+	MatchPath    = "path"    // the value at Argument equals Expected
+	MatchPathAll = "pathAll" // every value at Argument equals Expected
+	MatchPathAny = "pathAny" // some value at Argument equals Expected
+	MatchStatus  = "status"  // the HTTP status equals Expected
+	MatchError   = "error"   // the error code equals Expected, or any error if Expected is true
+)
+
+// Waiter polls an API call until its result matches an acceptor.
+type Waiter struct {
+	Name        string // used in errors (e.g. "TableExists")
+	Delay       time.Duration
+	MaxAttempts int
+	Acceptors   []WaiterAcceptor
+}
+
+// WaiterAcceptor matches the result of a call. Argument is a path to fields
+// of the output, separated by dots, where "[]" after a field of a list
+// selects every element (e.g. "Reservations[].Instances[].State.Name").
+type WaiterAcceptor struct {
+	State    string
+	Matcher  string
+	Argument string
+	Expected interface{}
+}
+
+// Wait calls attempt until its result is accepted, the waiter's attempts
+// are exhausted, or ctx is done. A nil error is returned when an acceptor
+// in the success state matches. An error which matches no acceptor ends the
+// wait.
+func (w *Waiter) Wait(ctx context.Context, attempt func(ctx context.Context) (interface{}, error)) error {
+	for n := 1; ; n++ {
+		output, err := attempt(ctx)
+		state, matched := w.match(output, err)
+		switch {
+		case matched && state == WaiterSuccess:
+			return nil
+		case matched && state == WaiterFailure:
+			if err != nil {
+				return fmt.Errorf("aws: waiter %s failed: %w", w.Name, err)
+			}
+			return fmt.Errorf("aws: waiter %s failed", w.Name)
+		case !matched && err != nil:
+			return err
+		}
+		if n >= w.MaxAttempts {
+			return fmt.Errorf("aws: waiter %s: gave up after %d attempts", w.Name, n)
+		}
+		timer := time.NewTimer(w.Delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (w *Waiter) match(output interface{}, err error) (state string, matched bool) {
+	for _, acceptor := range w.Acceptors {
+		if acceptor.matches(output, err) {
+			return acceptor.State, true
+		}
+	}
+	return "", false
+}
+
+func (acceptor *WaiterAcceptor) matches(output interface{}, err error) bool {
+	switch acceptor.Matcher {
+	case MatchError:
+		if expected, ok := acceptor.Expected.(bool); ok {
+			return (err != nil) == expected
+		}
+		var coder ErrorCoder
+		return errors.As(err, &coder) && coder.ErrorCode() == fmt.Sprint(acceptor.Expected)
+	case MatchStatus:
+		status := 200
+		var apierr APIError
+		if errors.As(err, &apierr) {
+			status = apierr.HTTPStatusCode()
+		} else if err != nil {
+			return false
+		}
+		return fmt.Sprint(status) == fmt.Sprint(acceptor.Expected)
+	}
+	if err != nil {
+		return false
+	}
+	values := WaiterPath(output, acceptor.Argument)
+	switch acceptor.Matcher {
+	case MatchPath:
+		return len(values) == 1 && waiterEqual(values[0], acceptor.Expected)
+	case MatchPathAll, MatchPathAny:
+		if len(values) == 0 {
+			return false
+		}
+		for _, value := range values {
+			if waiterEqual(value, acceptor.Expected) != (acceptor.Matcher == MatchPathAll) {
+				return acceptor.Matcher == MatchPathAny
+			}
+		}
+		return acceptor.Matcher == MatchPathAll
+	}
+	return false
+}
+
+func waiterEqual(value, expected interface{}) bool {
+	return fmt.Sprint(value) == fmt.Sprint(expected)
+}
+
+// WaiterPath returns the values at path in v. See WaiterAcceptor. Nil
+// pointers and missing fields have no values.
+func WaiterPath(v interface{}, path string) []interface{} {
+	values := []reflect.Value{reflect.ValueOf(v)}
+	for _, field := range strings.Split(path, ".") {
+		project := strings.HasSuffix(field, "[]")
+		field = strings.TrimSuffix(field, "[]")
+		var next []reflect.Value
+		for _, value := range values {
+			value = reflect.Indirect(value)
+			if value.Kind() != reflect.Struct {
+				continue
+			}
+			value = value.FieldByName(field)
+			if !value.IsValid() || (value.Kind() == reflect.Ptr && value.IsNil()) {
+				continue
+			}
+			if !project {
+				next = append(next, value)
+				continue
+			}
+			if value.Kind() != reflect.Slice {
+				continue
+			}
+			for i := 0; i < value.Len(); i++ {
+				next = append(next, value.Index(i))
+			}
+		}
+		values = next
+	}
+	var results []interface{}
+	for _, value := range values {
+		value = reflect.Indirect(value)
+		if value.IsValid() && value.CanInterface() {
+			results = append(results, value.Interface())
+		}
+	}
+	return results
+}
@@ -0,0 +1,131 @@
+// Copyright 2013, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testWaiterInstance struct {
+	State *struct{ Name string }
+}
+
+type testWaiterOutput struct {
+	Status       string
+	Reservations []struct {
+		Instances []testWaiterInstance
+	}
+}
+
+func testWaiterReservations(states ...string) *testWaiterOutput {
+	output := new(testWaiterOutput)
+	output.Reservations = make([]struct{ Instances []testWaiterInstance }, 1)
+	for _, state := range states {
+		instance := testWaiterInstance{}
+		if state != "" {
+			instance.State = &struct{ Name string }{state}
+		}
+		output.Reservations[0].Instances = append(output.Reservations[0].Instances, instance)
+	}
+	return output
+}
+
+func TestWaiterPath(t *testing.T) {
+	output := testWaiterReservations("running", "", "pending")
+	output.Status = "ACTIVE"
+	if values := WaiterPath(output, "Status"); !reflect.DeepEqual(values, []interface{}{"ACTIVE"}) {
+		t.Errorf("Status %v", values)
+	}
+	values := WaiterPath(output, "Reservations[].Instances[].State.Name")
+	if !reflect.DeepEqual(values, []interface{}{"running", "pending"}) {
+		t.Errorf("Name %v", values)
+	}
+	if values := WaiterPath(output, "Missing.Field"); len(values) != 0 {
+		t.Errorf("Missing %v", values)
+	}
+}
+
+func TestWaiter(t *testing.T) {
+	waiter := &Waiter{
+		Name:        "InstanceRunning",
+		Delay:       time.Millisecond,
+		MaxAttempts: 5,
+		Acceptors: []WaiterAcceptor{
+			{WaiterSuccess, MatchPathAll, "Reservations[].Instances[].State.Name", "running"},
+			{WaiterFailure, MatchPathAny, "Reservations[].Instances[].State.Name", "terminated"},
+			{WaiterRetry, MatchError, "", "InvalidInstanceID.NotFound"},
+		},
+	}
+	var responses []func() (interface{}, error)
+	attempt := func(ctx context.Context) (interface{}, error) {
+		resp := responses[0]
+		responses = responses[1:]
+		return resp()
+	}
+	output := func(states ...string) func() (interface{}, error) {
+		return func() (interface{}, error) { return testWaiterReservations(states...), nil }
+	}
+	fail := func(code string) func() (interface{}, error) {
+		return func() (interface{}, error) { return nil, testCodedError(code) }
+	}
+
+	responses = append(responses, fail("InvalidInstanceID.NotFound"), output("pending", "running"), output("running", "running"))
+	if err := waiter.Wait(context.Background(), attempt); err != nil || len(responses) != 0 {
+		t.Errorf("error %v; %d responses remain", err, len(responses))
+	}
+
+	responses = append(responses[:0], output("pending"), output("terminated", "running"))
+	if err := waiter.Wait(context.Background(), attempt); err == nil || !strings.Contains(err.Error(), "InstanceRunning failed") {
+		t.Errorf("error %v", err)
+	}
+
+	responses = append(responses[:0], fail("AccessDenied"))
+	if err := waiter.Wait(context.Background(), attempt); !errors.Is(err, error(testCodedError("AccessDenied"))) {
+		t.Errorf("error %v", err)
+	}
+
+	for i := 0; i < waiter.MaxAttempts; i++ {
+		responses = append(responses, output("pending"))
+	}
+	if err := waiter.Wait(context.Background(), attempt); err == nil || !strings.Contains(err.Error(), "5 attempts") {
+		t.Errorf("error %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	responses = append(responses[:0], output("pending"))
+	if err := waiter.Wait(ctx, attempt); err != context.Canceled {
+		t.Errorf("error %v", err)
+	}
+}
+
+func TestWaiterStatus(t *testing.T) {
+	waiter := &Waiter{
+		Name:        "ObjectNotExists",
+		Delay:       time.Millisecond,
+		MaxAttempts: 2,
+		Acceptors: []WaiterAcceptor{
+			{WaiterSuccess, MatchStatus, "", 404},
+			{WaiterSuccess, MatchError, "", false},
+		},
+	}
+	err := waiter.Wait(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return nil, &JSONError{Code: "NotFound", StatusCode: 404}
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	err = waiter.Wait(context.Background(), func(ctx context.Context) (interface{}, error) {
+		return struct{}{}, nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}